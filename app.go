@@ -3,22 +3,59 @@ package main
 import (
 	"context"
 	"fmt"
-	gosruntime "runtime"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/JadlionHD/Enty/internal/certs"
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/control"
+	"github.com/JadlionHD/Enty/internal/dns"
+	"github.com/JadlionHD/Enty/internal/events"
+	"github.com/JadlionHD/Enty/internal/hosts"
+	"github.com/JadlionHD/Enty/internal/mail"
+	"github.com/JadlionHD/Enty/internal/project"
+	"github.com/JadlionHD/Enty/internal/tasks"
+	"github.com/JadlionHD/Enty/internal/termbridge"
 	"github.com/JadlionHD/Enty/internal/utils"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
-	ctx             context.Context
-	terminalManager *utils.TerminalManager
+	ctx              context.Context
+	bus              events.Bus
+	terminalManager  *utils.TerminalManager
+	hostsManager     *hosts.Manager
+	projects         *project.Registry
+	manifestWatchers map[string]func()
+	manifestMutex    sync.Mutex
+	certAuthority    *certs.Authority
+	stopCertRotation func()
+	dnsServer        *dns.Server
+	dnsMutex         sync.Mutex
+	mailServer       *mail.Server
+	mailMessages     *mail.Store
+	mailMutex        sync.Mutex
+	controlServer    *control.Server
+	controlMutex     sync.Mutex
+	terminalBridge   *termbridge.Server
+	bridgeMutex      sync.Mutex
+	playbacks        map[string]context.CancelFunc
+	playbackMutex    sync.Mutex
+	commands         map[string]context.CancelFunc
+	commandMutex     sync.Mutex
+	tasks            *tasks.Store
+	tasksMutex       sync.Mutex
+	saveSessionsOnce sync.Once
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
+// NewApp creates a new App application struct that publishes its events on bus
+func NewApp(bus events.Bus) *App {
 	return &App{
-		terminalManager: utils.NewTerminalManager(),
+		bus:              bus,
+		terminalManager:  utils.NewTerminalManager(),
+		hostsManager:     newHostsManager(),
+		manifestWatchers: make(map[string]func()),
 	}
 }
 
@@ -26,6 +63,41 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	if statePath, err := config.DataPath("run", "sessions.json"); err == nil {
+		a.terminalManager.SetStatePath(statePath)
+	}
+	a.loadProjects()
+	a.startCertificateAuthority()
+	a.startDNSIfEnabled()
+	a.startMailIfEnabled()
+	a.startControlIfEnabled()
+	a.startTerminalBridgeIfEnabled()
+	a.regenerateShimsOnStartup()
+}
+
+// shutdown is called when the app is closing
+func (a *App) shutdown(ctx context.Context) {
+	if a.stopCertRotation != nil {
+		a.stopCertRotation()
+	}
+	a.StopDNSServer()
+	a.StopMailServer()
+	a.StopControlServer()
+	a.StopTerminalBridge()
+
+	a.commandMutex.Lock()
+	for _, cancel := range a.commands {
+		cancel()
+	}
+	a.commandMutex.Unlock()
+
+	a.manifestMutex.Lock()
+	for _, stop := range a.manifestWatchers {
+		stop()
+	}
+	a.manifestMutex.Unlock()
+	a.saveTerminalSessions()
+	a.terminalManager.CleanupAll()
 }
 
 // Greet returns a greeting for the given name
@@ -37,32 +109,82 @@ func (a *App) Greet(name string) string {
 CreateTerminalSession creates a new terminal session using only sessionID and terminalType.
 */
 func (a *App) CreateTerminalSession(sessionID, terminalType string) error {
-	session, err := a.terminalManager.CreateSession(utils.CreateSessionOptions{
+	return a.startTerminalSession(utils.CreateSessionOptions{
 		SessionID:    sessionID,
 		TerminalType: terminalType,
 	})
+}
+
+// CreateTerminalSessionWithOptions creates a terminal session limited to opts.Services on PATH,
+// started in opts.Dir with opts.Env set, and runs opts.InitialCommand once the shell is up
+func (a *App) CreateTerminalSessionWithOptions(opts utils.CreateSessionOptions) error {
+	if opts.SessionID == "" {
+		return fmt.Errorf("session ID is required")
+	}
+	return a.startTerminalSession(opts)
+}
+
+// startTerminalSession creates and starts a session, forwarding its output as Wails events
+func (a *App) startTerminalSession(opts utils.CreateSessionOptions) error {
+	if opts.Executable == "" {
+		if profile, ok := findTerminalProfile(opts.TerminalType); ok {
+			applyTerminalProfile(&opts, profile)
+		}
+	}
+	opts.Dir = expandHome(opts.Dir)
+	if opts.Dir != "" {
+		if stat, err := os.Stat(opts.Dir); err != nil || !stat.IsDir() {
+			return fmt.Errorf("working directory %s does not exist", opts.Dir)
+		}
+	}
+	terminalSettings := config.LiveSettingsManager().Get().Terminal
+	if opts.ScrollbackSize == 0 {
+		opts.ScrollbackSize = terminalSettings.ScrollbackSize
+	}
+	if opts.Timeout == nil {
+		opts.Timeout = &terminalSettings.Timeout
+	}
+	sessionID := opts.SessionID
+	session, err := a.terminalManager.CreateSession(opts)
 	if err != nil {
 		return err
 	}
 
+	session.SetTimeoutWarningCallback(func(id string, remaining time.Duration) {
+		a.bus.Emit("terminal:timeout-warning", map[string]interface{}{
+			"sessionID": id,
+			"seconds":   int(remaining.Seconds()),
+		})
+	})
+	session.SetDetachCallback(func(id string) {
+		a.bus.Emit("terminal:detached", map[string]interface{}{
+			"sessionID": id,
+		})
+	})
+
 	err = session.Start()
 	if err != nil {
 		a.terminalManager.RemoveSession(sessionID)
 		return err
 	}
 
-	// Start the read loop with callback functions for Wails events
+	// Start the read loop with callback functions for terminal events
 	session.StartReadLoop(
 		func(data string) {
-			runtime.EventsEmit(a.ctx, "terminal:data", map[string]interface{}{
+			a.bus.Emit("terminal:data", map[string]interface{}{
 				"sessionID": sessionID,
 				"data":      data,
+				"offset":    session.OutputOffset(),
 			})
 		},
 		func(message string) {
-			runtime.EventsEmit(a.ctx, "terminal:exit", map[string]interface{}{
+			info := session.Info()
+			a.bus.Emit("terminal:exit", map[string]interface{}{
 				"sessionID": sessionID,
 				"message":   message,
+				"exitCode":  info.ExitCode,
+				"signal":    info.Signal,
+				"exitedAt":  info.ExitedAt,
 			})
 			a.terminalManager.RemoveSession(sessionID)
 		},
@@ -71,6 +193,32 @@ func (a *App) CreateTerminalSession(sessionID, terminalType string) error {
 	return nil
 }
 
+// TerminalAttachment is the scrollback replayed when the UI reattaches to a running session
+type TerminalAttachment struct {
+	SessionID string `json:"sessionID"`
+	Data      string `json:"data"`
+	// Offset is the output position Data ends at; terminal:data events with an
+	// offset at or below it are already contained in Data
+	Offset  int64 `json:"offset"`
+	Running bool  `json:"running"`
+}
+
+// AttachTerminalSession returns the buffered output of an existing session so a reloaded UI
+// can replay it before handling live terminal:data events
+func (a *App) AttachTerminalSession(sessionID string) (*TerminalAttachment, error) {
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	data, offset := session.Scrollback()
+	return &TerminalAttachment{
+		SessionID: sessionID,
+		Data:      data,
+		Offset:    offset,
+		Running:   session.IsRunning(),
+	}, nil
+}
+
 // WriteToTerminal sends input to a specific terminal session
 func (a *App) WriteToTerminal(sessionID, input string) error {
 	session, err := a.terminalManager.GetSession(sessionID)
@@ -103,19 +251,26 @@ func (a *App) ResizeTerminalSession(sessionID string, cols, rows int) error {
 	return session.Resize(cols, rows)
 }
 
+// SetTerminalSessionTimeout changes the inactivity policy of a running session; Minutes 0 disables its timeout
+func (a *App) SetTerminalSessionTimeout(sessionID string, timeout config.TerminalTimeout) error {
+	if timeout.Action != "" && timeout.Action != config.TimeoutActionKill && timeout.Action != config.TimeoutActionDetach {
+		return fmt.Errorf("unknown timeout action %q", timeout.Action)
+	}
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	session.SetTimeoutPolicy(timeout)
+	return nil
+}
+
+// GetSessionInfo returns the status of a terminal session: its pid, working directory, start
+// time and, once it has ended, its exit code or signal. Recently closed sessions remain available.
+func (a *App) GetSessionInfo(sessionID string) (*utils.SessionInfo, error) {
+	return a.terminalManager.SessionInfo(sessionID)
+}
+
 // ListTerminalSessions returns all active terminal session IDs
 func (a *App) ListTerminalSessions() []string {
 	return a.terminalManager.ListSessions()
 }
-
-// GetAvailableTerminalTypes returns available terminal types for the current platform
-func (a *App) GetAvailableTerminalTypes() []string {
-	switch gosruntime.GOOS {
-	case "windows":
-		return []string{"powershell", "cmd"}
-	case "darwin", "linux":
-		return []string{"bash"}
-	default:
-		return []string{"bash"}
-	}
-}
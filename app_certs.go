@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/certs"
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// certRotationInterval is how often leaf certificates are checked for expiry
+const certRotationInterval = 12 * time.Hour
+
+// startCertificateAuthority loads or creates the local root CA and starts leaf rotation
+func (a *App) startCertificateAuthority() {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		log.Printf("Certificate authority disabled: %v", err)
+		return
+	}
+
+	authority, err := certs.NewAuthority(filepath.Join(dataDir, "certs"))
+	if err != nil {
+		log.Printf("Certificate authority disabled: %v", err)
+		return
+	}
+
+	a.certAuthority = authority
+	a.stopCertRotation = authority.WatchRotation(certRotationInterval)
+}
+
+// certificateAuthority returns the loaded CA or an error when it failed to initialize
+func (a *App) certificateAuthority() (*certs.Authority, error) {
+	if a.certAuthority == nil {
+		return nil, fmt.Errorf("certificate authority is not available")
+	}
+	return a.certAuthority, nil
+}
+
+// GetCACertificatePath returns the root CA certificate path so it can be trusted manually
+func (a *App) GetCACertificatePath() (string, error) {
+	authority, err := a.certificateAuthority()
+	if err != nil {
+		return "", err
+	}
+	return authority.CACertPath(), nil
+}
+
+// IssueSiteCertificate issues (or returns the current) HTTPS certificate for a project hostname
+func (a *App) IssueSiteCertificate(hostname string) (*certs.SiteCertificate, error) {
+	authority, err := a.certificateAuthority()
+	if err != nil {
+		return nil, err
+	}
+	return authority.IssueSite(hostname)
+}
+
+// ListSiteCertificates returns all issued site certificates
+func (a *App) ListSiteCertificates() ([]certs.SiteCertificate, error) {
+	authority, err := a.certificateAuthority()
+	if err != nil {
+		return nil, err
+	}
+	return authority.ListSites()
+}
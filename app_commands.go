@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/runner"
+)
+
+// RunCommand runs argv non-interactively in the isolated environment, with PATH limited to service
+// when given, and returns once it has finished. Output is streamed as command:stdout and
+// command:stderr events and the result is also emitted as command:exit, all carrying runID.
+// A timeoutSeconds of 0 disables the timeout; CancelCommand stops the command early.
+func (a *App) RunCommand(runID, service string, argv []string, cwd string, timeoutSeconds int) (*runner.Result, error) {
+	return a.runCommand(runID, runner.Options{
+		Args:    argv,
+		Dir:     expandHome(cwd),
+		Service: service,
+		Timeout: time.Duration(timeoutSeconds) * time.Second,
+	})
+}
+
+// CancelCommand stops a command started by RunCommand, including its child processes
+func (a *App) CancelCommand(runID string) error {
+	a.commandMutex.Lock()
+	cancel, exists := a.commands[runID]
+	a.commandMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("command %s not found", runID)
+	}
+	cancel()
+	return nil
+}
+
+func (a *App) runCommand(runID string, opts runner.Options) (*runner.Result, error) {
+	if runID == "" {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		runID = "run-" + hex.EncodeToString(buf)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.commandMutex.Lock()
+	if _, exists := a.commands[runID]; exists {
+		a.commandMutex.Unlock()
+		return nil, fmt.Errorf("command %s is already running", runID)
+	}
+	if a.commands == nil {
+		a.commands = make(map[string]context.CancelFunc)
+	}
+	a.commands[runID] = cancel
+	a.commandMutex.Unlock()
+
+	defer func() {
+		a.commandMutex.Lock()
+		delete(a.commands, runID)
+		a.commandMutex.Unlock()
+	}()
+
+	opts.OnStdout = func(data string) {
+		a.bus.Emit("command:stdout", map[string]interface{}{"runID": runID, "data": data})
+	}
+	opts.OnStderr = func(data string) {
+		a.bus.Emit("command:stderr", map[string]interface{}{"runID": runID, "data": data})
+	}
+
+	result, err := runner.Run(ctx, opts)
+	if err != nil {
+		a.bus.Emit("command:exit", map[string]interface{}{"runID": runID, "error": err.Error()})
+		return nil, err
+	}
+	a.bus.Emit("command:exit", map[string]interface{}{"runID": runID, "result": result})
+	return result, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/control"
+	"github.com/JadlionHD/Enty/internal/install"
+	"github.com/JadlionHD/Enty/internal/service"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// startControlIfEnabled starts the local control API when enabled in settings
+func (a *App) startControlIfEnabled() {
+	if !config.LiveSettingsManager().Get().Control.Enabled {
+		return
+	}
+	if err := a.StartControlServer(); err != nil {
+		log.Printf("Failed to start control server: %v", err)
+	}
+}
+
+// StartControlServer starts the local JSON-RPC control API
+func (a *App) StartControlServer() error {
+	a.controlMutex.Lock()
+	defer a.controlMutex.Unlock()
+
+	if a.controlServer != nil {
+		return nil
+	}
+
+	endpointFile, err := config.DataPath("run", "control.json")
+	if err != nil {
+		return err
+	}
+	socketPath, err := config.DataPath("run", "enty.sock")
+	if err != nil {
+		return err
+	}
+
+	server := control.NewServer(control.ServerOptions{
+		EndpointFile: endpointFile,
+		SocketPath:   socketPath,
+		TCPAddr:      net.JoinHostPort("127.0.0.1", strconv.Itoa(config.LiveSettingsManager().Get().Control.Port)),
+	})
+	a.registerControlMethods(server)
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	a.controlServer = server
+	log.Printf("Control server listening on %s %s", server.Endpoint().Network, server.Endpoint().Address)
+	return nil
+}
+
+// StopControlServer stops the local control API
+func (a *App) StopControlServer() error {
+	a.controlMutex.Lock()
+	defer a.controlMutex.Unlock()
+
+	if a.controlServer == nil {
+		return nil
+	}
+	err := a.controlServer.Stop()
+	a.controlServer = nil
+	return err
+}
+
+// IsControlServerRunning returns whether the local control API is running
+func (a *App) IsControlServerRunning() bool {
+	a.controlMutex.Lock()
+	defer a.controlMutex.Unlock()
+	return a.controlServer != nil
+}
+
+type serviceParams struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+}
+
+type sessionParams struct {
+	utils.CreateSessionOptions
+	ProjectID string `json:"projectID"`
+}
+
+type signalParams struct {
+	SessionID string `json:"sessionID"`
+	// PID 0 targets the foreground process group of the terminal
+	PID    int    `json:"pid"`
+	Signal string `json:"signal"`
+}
+
+type searchParams struct {
+	SessionID string `json:"sessionID"`
+	Pattern   string `json:"pattern"`
+	Regex     bool   `json:"regex"`
+}
+
+type exportParams struct {
+	SessionID string `json:"sessionID"`
+	Format    string `json:"format"`
+}
+
+type taskParams struct {
+	ProjectID string   `json:"projectID"`
+	Names     []string `json:"names"`
+	SessionID string   `json:"sessionID"`
+}
+
+type commandParams struct {
+	RunID   string   `json:"runID"`
+	Service string   `json:"service"`
+	Args    []string `json:"args"`
+	Cwd     string   `json:"cwd"`
+	// Timeout is in seconds
+	Timeout int `json:"timeout"`
+}
+
+// registerControlMethods exposes App functionality as JSON-RPC methods
+func (a *App) registerControlMethods(server *control.Server) {
+	server.Register("service.list", func(json.RawMessage) (interface{}, error) {
+		return service.List(), nil
+	})
+	server.Register("service.start", func(params json.RawMessage) (interface{}, error) {
+		var p serviceParams
+		if err := decodeServiceParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.StartService(p.Service, p.Version)
+	})
+	server.Register("service.stop", func(params json.RawMessage) (interface{}, error) {
+		var p serviceParams
+		if err := decodeServiceParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, a.StopService(p.Service)
+	})
+	server.Register("version.list", func(params json.RawMessage) (interface{}, error) {
+		var p serviceParams
+		if err := decodeServiceParams(params, &p); err != nil {
+			return nil, err
+		}
+		return install.Available(p.Service)
+	})
+	server.Register("version.install", func(params json.RawMessage) (interface{}, error) {
+		var p serviceParams
+		if err := decodeServiceParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Version == "" {
+			return nil, control.InvalidParams("version is required")
+		}
+		return a.InstallServiceVersion(p.Service, p.Version)
+	})
+	server.Register("project.list", func(json.RawMessage) (interface{}, error) {
+		return a.ListProjects()
+	})
+	server.Register("session.list", func(json.RawMessage) (interface{}, error) {
+		return a.terminalManager.Sessions(), nil
+	})
+	server.Register("session.info", func(params json.RawMessage) (interface{}, error) {
+		var p sessionParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.SessionID == "" {
+			return nil, control.InvalidParams("sessionID is required")
+		}
+		return a.GetSessionInfo(p.SessionID)
+	})
+	server.Register("session.search", func(params json.RawMessage) (interface{}, error) {
+		var p searchParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.SessionID == "" || p.Pattern == "" {
+			return nil, control.InvalidParams("sessionID and pattern are required")
+		}
+		return a.SearchTerminalOutput(p.SessionID, p.Pattern, p.Regex)
+	})
+	server.Register("session.export", func(params json.RawMessage) (interface{}, error) {
+		var p exportParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.SessionID == "" {
+			return nil, control.InvalidParams("sessionID is required")
+		}
+		return a.ExportTerminalOutput(p.SessionID, p.Format)
+	})
+	server.Register("command.run", func(params json.RawMessage) (interface{}, error) {
+		var p commandParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.Args) == 0 {
+			return nil, control.InvalidParams("args is required")
+		}
+		return a.RunCommand(p.RunID, p.Service, p.Args, p.Cwd, p.Timeout)
+	})
+	server.Register("command.cancel", func(params json.RawMessage) (interface{}, error) {
+		var p commandParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, a.CancelCommand(p.RunID)
+	})
+	server.Register("session.processes", func(params json.RawMessage) (interface{}, error) {
+		var p sessionParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.GetTerminalProcessTree(p.SessionID)
+	})
+	server.Register("session.signal", func(params json.RawMessage) (interface{}, error) {
+		var p signalParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Signal == "" {
+			return nil, control.InvalidParams("signal is required")
+		}
+		return nil, a.SignalTerminalProcess(p.SessionID, p.PID, p.Signal)
+	})
+	server.Register("task.list", func(params json.RawMessage) (interface{}, error) {
+		var p taskParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return a.ListTasks(p.ProjectID)
+	})
+	server.Register("task.run", func(params json.RawMessage) (interface{}, error) {
+		var p taskParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if len(p.Names) == 0 {
+			return nil, control.InvalidParams("names is required")
+		}
+		sessionID, err := a.RunTasks(p.ProjectID, p.Names, p.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"sessionID": sessionID}, nil
+	})
+	server.Register("session.bridge", func(json.RawMessage) (interface{}, error) {
+		return a.GetTerminalBridgeInfo(), nil
+	})
+	server.Register("session.create", func(params json.RawMessage) (interface{}, error) {
+		var p sessionParams
+		if err := control.DecodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.SessionID == "" {
+			p.SessionID = newControlSessionID()
+		}
+
+		if p.ProjectID != "" {
+			if err := a.applyProjectSessionOptions(&p.CreateSessionOptions, p.ProjectID); err != nil {
+				return nil, err
+			}
+		}
+		if err := a.startTerminalSession(p.CreateSessionOptions); err != nil {
+			return nil, err
+		}
+		return map[string]string{"sessionID": p.SessionID}, nil
+	})
+}
+
+func decodeServiceParams(params json.RawMessage, p *serviceParams) error {
+	if err := control.DecodeParams(params, p); err != nil {
+		return err
+	}
+	if p.Service == "" {
+		return control.InvalidParams("service is required")
+	}
+	return nil
+}
+
+// newControlSessionID returns a random ID for sessions created without one
+func newControlSessionID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return "control-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/dns"
+)
+
+// startDNSIfEnabled starts the development DNS server when enabled in settings
+func (a *App) startDNSIfEnabled() {
+	if !config.LiveSettingsManager().Get().DNS.Enabled {
+		return
+	}
+	if err := a.StartDNSServer(); err != nil {
+		log.Printf("Failed to start DNS server: %v", err)
+	}
+}
+
+// StartDNSServer starts the embedded DNS resolver for wildcard development domains
+func (a *App) StartDNSServer() error {
+	a.dnsMutex.Lock()
+	defer a.dnsMutex.Unlock()
+
+	if a.dnsServer != nil && a.dnsServer.IsRunning() {
+		return nil
+	}
+
+	settings := config.LiveSettingsManager().Get().DNS
+	server := dns.NewServer(dns.ServerOptions{
+		Addr:    net.JoinHostPort(settings.Address, strconv.Itoa(settings.Port)),
+		Domains: settings.Domains,
+	})
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	a.dnsServer = server
+	log.Printf("DNS server listening on %s", server.Addr())
+	return nil
+}
+
+// StopDNSServer stops the embedded DNS resolver
+func (a *App) StopDNSServer() error {
+	a.dnsMutex.Lock()
+	defer a.dnsMutex.Unlock()
+
+	if a.dnsServer == nil {
+		return nil
+	}
+	err := a.dnsServer.Stop()
+	a.dnsServer = nil
+	return err
+}
+
+// IsDNSServerRunning returns whether the embedded DNS resolver is running
+func (a *App) IsDNSServerRunning() bool {
+	a.dnsMutex.Lock()
+	defer a.dnsMutex.Unlock()
+	return a.dnsServer != nil && a.dnsServer.IsRunning()
+}
+
+// GetDNSServerAddress returns the address the DNS resolver listens on, or an empty string when stopped
+func (a *App) GetDNSServerAddress() string {
+	a.dnsMutex.Lock()
+	defer a.dnsMutex.Unlock()
+	if a.dnsServer == nil {
+		return ""
+	}
+	return a.dnsServer.Addr()
+}
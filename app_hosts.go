@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/hosts"
+)
+
+// newHostsManager creates the hosts file manager, backing up the original into the data directory
+func newHostsManager() *hosts.Manager {
+	backupPath, err := config.DataPath("backups", "hosts.bak")
+	if err != nil {
+		log.Printf("Hosts file backup disabled: %v", err)
+		backupPath = ""
+	}
+	return hosts.NewManager(hosts.DefaultPath(), backupPath)
+}
+
+// GetHostsFilePath returns the hosts file Enty manages
+func (a *App) GetHostsFilePath() string {
+	return a.hostsManager.Path()
+}
+
+// GetHostsEntries returns the entries inside the Enty block of the hosts file
+func (a *App) GetHostsEntries() ([]hosts.Entry, error) {
+	return a.hostsManager.Entries()
+}
+
+// AddHostsEntry maps a hostname to an IP address in the Enty block of the hosts file
+func (a *App) AddHostsEntry(ip, hostname string) error {
+	return a.hostsManager.Add(hosts.Entry{IP: ip, Hostname: hostname})
+}
+
+// RemoveHostsEntry removes a hostname from the Enty block of the hosts file
+func (a *App) RemoveHostsEntry(hostname string) error {
+	return a.hostsManager.Remove(hostname)
+}
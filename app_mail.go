@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/mail"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// startMailIfEnabled opens the mail store and starts the SMTP catcher when enabled in settings
+func (a *App) startMailIfEnabled() {
+	if !config.LiveSettingsManager().Get().Mail.Enabled {
+		return
+	}
+	if err := a.StartMailServer(); err != nil {
+		log.Printf("Failed to start mail server: %v", err)
+	}
+}
+
+// mailStore returns the message store, opening it on first use
+func (a *App) mailStore() (*mail.Store, error) {
+	a.mailMutex.Lock()
+	defer a.mailMutex.Unlock()
+
+	if a.mailMessages != nil {
+		return a.mailMessages, nil
+	}
+
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	store, err := mail.NewStore(filepath.Join(dataDir, "mail"))
+	if err != nil {
+		return nil, err
+	}
+	a.mailMessages = store
+	return store, nil
+}
+
+// StartMailServer starts the SMTP mail catcher on the configured port
+func (a *App) StartMailServer() error {
+	store, err := a.mailStore()
+	if err != nil {
+		return err
+	}
+
+	a.mailMutex.Lock()
+	defer a.mailMutex.Unlock()
+
+	if a.mailServer != nil && a.mailServer.IsRunning() {
+		return nil
+	}
+
+	settings := config.LiveSettingsManager().Get().Mail
+	addr := net.JoinHostPort(settings.Address, strconv.Itoa(settings.Port))
+	server := mail.NewServer(addr, store, func(summary mail.Summary) {
+		a.bus.Emit("mail:received", summary)
+	})
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	a.mailServer = server
+	log.Printf("Mail catcher listening on %s", server.Addr())
+	return nil
+}
+
+// StopMailServer stops the SMTP mail catcher
+func (a *App) StopMailServer() error {
+	a.mailMutex.Lock()
+	defer a.mailMutex.Unlock()
+
+	if a.mailServer == nil {
+		return nil
+	}
+	err := a.mailServer.Stop()
+	a.mailServer = nil
+	return err
+}
+
+// IsMailServerRunning returns whether the SMTP mail catcher is running
+func (a *App) IsMailServerRunning() bool {
+	a.mailMutex.Lock()
+	defer a.mailMutex.Unlock()
+	return a.mailServer != nil && a.mailServer.IsRunning()
+}
+
+// ListMails returns all caught messages, newest first
+func (a *App) ListMails() ([]mail.Summary, error) {
+	store, err := a.mailStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.List()
+}
+
+// GetMail returns a caught message with its text, HTML and attachment list
+func (a *App) GetMail(id string) (*mail.Message, error) {
+	store, err := a.mailStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.Get(id)
+}
+
+// SaveMailAttachment asks for a destination and writes an attachment to it.
+// It returns the saved path, or an empty string when the dialog was cancelled.
+func (a *App) SaveMailAttachment(id string, index int) (string, error) {
+	store, err := a.mailStore()
+	if err != nil {
+		return "", err
+	}
+
+	attachment, err := store.Attachment(id, index)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save attachment",
+		DefaultFilename: filepath.Base(attachment.Filename),
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, attachment.Data(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to save attachment: %w", err)
+	}
+	return path, nil
+}
+
+// DeleteMail removes a single caught message
+func (a *App) DeleteMail(id string) error {
+	store, err := a.mailStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(id)
+}
+
+// PurgeMails removes all caught messages
+func (a *App) PurgeMails() error {
+	store, err := a.mailStore()
+	if err != nil {
+		return err
+	}
+	return store.Purge()
+}
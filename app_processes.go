@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JadlionHD/Enty/internal/proctree"
+)
+
+// GetTerminalProcessTree returns the shell of a session and every process started beneath it,
+// with CPU and memory usage per process
+func (a *App) GetTerminalProcessTree(sessionID string) (*proctree.Process, error) {
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	info := session.Info()
+	if !info.Running || info.PID == 0 {
+		return nil, fmt.Errorf("terminal session %s is not running", sessionID)
+	}
+	return proctree.Tree(info.PID)
+}
+
+// SignalTerminalProcess sends SIGINT, SIGTERM or SIGKILL to a process of a session. A pid of 0
+// targets the foreground process group, for example a stuck server started from the shell.
+// Only processes beneath the session's shell can be signalled.
+func (a *App) SignalTerminalProcess(sessionID string, pid int, signal string) error {
+	signal, err := proctree.ParseSignal(signal)
+	if err != nil {
+		return err
+	}
+	tree, err := a.GetTerminalProcessTree(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if pid == 0 {
+		session, err := a.terminalManager.GetSession(sessionID)
+		if err != nil {
+			return err
+		}
+		pgid, err := session.ForegroundProcessGroup()
+		if err != nil {
+			return err
+		}
+		if !tree.ContainsGroup(pgid) {
+			return fmt.Errorf("foreground process group %d does not belong to session %s", pgid, sessionID)
+		}
+		if err := proctree.SignalGroup(pgid, signal); err != nil {
+			return err
+		}
+		// Let a task chain waiting on the foreground command know it may not report back
+		session.NotifyInterrupt()
+		return nil
+	}
+
+	if !tree.Contains(pid) {
+		return fmt.Errorf("process %d does not belong to session %s", pid, sessionID)
+	}
+	return proctree.Signal(pid, signal)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// GetAvailableTerminalTypes returns the user's terminal profiles followed by the shells installed on this machine
+func (a *App) GetAvailableTerminalTypes() []string {
+	var types []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			types = append(types, name)
+		}
+	}
+
+	for _, profile := range config.LiveSettingsManager().Get().Terminal.Profiles {
+		add(profile.Name)
+	}
+	for _, shell := range utils.DiscoverShells() {
+		add(shell.Name)
+	}
+
+	if len(types) == 0 {
+		if gosruntime.GOOS == "windows" {
+			return []string{"powershell", "cmd"}
+		}
+		return []string{"sh"}
+	}
+	return types
+}
+
+// GetInstalledShells returns the shells discovered on this machine
+func (a *App) GetInstalledShells() []utils.Shell {
+	return utils.DiscoverShells()
+}
+
+// GetTerminalProfiles returns the user-defined terminal profiles
+func (a *App) GetTerminalProfiles() []config.TerminalProfile {
+	profiles := config.LiveSettingsManager().Get().Terminal.Profiles
+	if profiles == nil {
+		return []config.TerminalProfile{}
+	}
+	return profiles
+}
+
+// SaveTerminalProfile adds a terminal profile or replaces the one with the same name
+func (a *App) SaveTerminalProfile(profile config.TerminalProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	profile.Executable = strings.TrimSpace(profile.Executable)
+	if profile.Name == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if profile.Executable == "" {
+		return fmt.Errorf("profile executable is required")
+	}
+
+	return config.LiveSettingsManager().Update(func(settings *config.Settings) {
+		profiles := make([]config.TerminalProfile, 0, len(settings.Terminal.Profiles)+1)
+		replaced := false
+		for _, existing := range settings.Terminal.Profiles {
+			if strings.EqualFold(existing.Name, profile.Name) {
+				existing, replaced = profile, true
+			}
+			profiles = append(profiles, existing)
+		}
+		if !replaced {
+			profiles = append(profiles, profile)
+		}
+		settings.Terminal.Profiles = profiles
+	})
+}
+
+// DeleteTerminalProfile removes the terminal profile with the given name
+func (a *App) DeleteTerminalProfile(name string) error {
+	if _, ok := findTerminalProfile(name); !ok {
+		return fmt.Errorf("terminal profile %s not found", name)
+	}
+
+	return config.LiveSettingsManager().Update(func(settings *config.Settings) {
+		profiles := make([]config.TerminalProfile, 0, len(settings.Terminal.Profiles))
+		for _, existing := range settings.Terminal.Profiles {
+			if !strings.EqualFold(existing.Name, name) {
+				profiles = append(profiles, existing)
+			}
+		}
+		settings.Terminal.Profiles = profiles
+	})
+}
+
+func findTerminalProfile(name string) (config.TerminalProfile, bool) {
+	for _, profile := range config.LiveSettingsManager().Get().Terminal.Profiles {
+		if strings.EqualFold(profile.Name, name) {
+			return profile, true
+		}
+	}
+	return config.TerminalProfile{}, false
+}
+
+// applyTerminalProfile fills the session options from a profile; options already set, such as a
+// project's pinned services and environment, take precedence
+func applyTerminalProfile(opts *utils.CreateSessionOptions, profile config.TerminalProfile) {
+	opts.Executable = profile.Executable
+	opts.Args = profile.Args
+	if opts.Dir == "" {
+		opts.Dir = expandHome(profile.Cwd)
+	}
+	if len(opts.Services) == 0 {
+		opts.Services = profile.Services
+	}
+
+	if len(profile.Env) > 0 {
+		env := make(map[string]string, len(profile.Env)+len(opts.Env))
+		for key, value := range profile.Env {
+			env[key] = value
+		}
+		for key, value := range opts.Env {
+			env[key] = value
+		}
+		opts.Env = env
+	}
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/configwatch"
+	"github.com/JadlionHD/Enty/internal/project"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// loadProjects opens the project registry in the data directory
+func (a *App) loadProjects() {
+	path, err := config.DataPath("projects.json")
+	if err != nil {
+		log.Printf("Project registry disabled: %v", err)
+		return
+	}
+
+	registry, err := project.NewRegistry(path)
+	if err != nil {
+		log.Printf("Project registry disabled: %v", err)
+		return
+	}
+	a.projects = registry
+
+	for _, p := range registry.List() {
+		a.watchProjectManifest(p.ID, p.Root)
+	}
+}
+
+// manifestPollInterval is how often project manifests are checked for changes
+var manifestPollInterval = 2 * time.Second
+
+// watchProjectManifest applies the project's manifest now and whenever it changes. The first
+// check runs even when there is no manifest, so pins of one deleted while Enty was closed are
+// dropped, and deleting it later drops them too.
+func (a *App) watchProjectManifest(projectID, root string) {
+	a.manifestMutex.Lock()
+	defer a.manifestMutex.Unlock()
+
+	if stop, exists := a.manifestWatchers[projectID]; exists {
+		stop()
+	}
+	a.manifestWatchers[projectID] = configwatch.WatchFile(
+		filepath.Join(root, project.ManifestFileName),
+		manifestPollInterval,
+		func() { a.reloadProjectManifest(projectID) },
+	)
+}
+
+// unwatchProjectManifest stops watching a project's manifest
+func (a *App) unwatchProjectManifest(projectID string) {
+	a.manifestMutex.Lock()
+	defer a.manifestMutex.Unlock()
+
+	if stop, exists := a.manifestWatchers[projectID]; exists {
+		stop()
+		delete(a.manifestWatchers, projectID)
+	}
+}
+
+// reloadProjectManifest merges the manifest into the project's pins and notifies the frontend
+func (a *App) reloadProjectManifest(projectID string) {
+	p, err := a.GetProject(projectID)
+	if err != nil {
+		return
+	}
+
+	manifest, err := project.LoadManifest(p.Root)
+	if errors.Is(err, fs.ErrNotExist) {
+		// A deleted manifest no longer pins anything
+		if len(p.ManifestServices) == 0 {
+			return
+		}
+		manifest = &project.Manifest{}
+	} else if err != nil {
+		log.Printf("Failed to load manifest for project %s: %v", p.Name, err)
+		return
+	}
+
+	p.ApplyManifest(manifest)
+	if err := a.projects.Update(*p); err != nil {
+		log.Printf("Failed to apply manifest for project %s: %v", p.Name, err)
+		return
+	}
+
+	status := manifest.Validate(config.LivePathsConfigManager())
+	status.Path = filepath.Join(p.Root, project.ManifestFileName)
+	a.bus.Emit("project:manifest-changed", map[string]interface{}{
+		"projectID": projectID,
+		"status":    status,
+	})
+}
+
+// projectRegistry returns the loaded registry or an error when it failed to load
+func (a *App) projectRegistry() (*project.Registry, error) {
+	if a.projects == nil {
+		return nil, fmt.Errorf("project registry is not available")
+	}
+	return a.projects, nil
+}
+
+// ListProjects returns all registered projects
+func (a *App) ListProjects() ([]project.Project, error) {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return registry.List(), nil
+}
+
+// GetProject returns a registered project by ID
+func (a *App) GetProject(id string) (*project.Project, error) {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return registry.Get(id)
+}
+
+// AddProject registers a project directory with its hostname and pinned service versions
+func (a *App) AddProject(p project.Project) (*project.Project, error) {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick up requirements declared in the project's manifest right away
+	if manifest, err := project.LoadManifest(p.Root); err == nil {
+		p.ApplyManifest(manifest)
+	}
+
+	added, err := registry.Add(p)
+	if err != nil {
+		return nil, err
+	}
+	a.watchProjectManifest(added.ID, added.Root)
+	return added, nil
+}
+
+// UpdateProject saves changes to a registered project
+func (a *App) UpdateProject(p project.Project) error {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return err
+	}
+	return registry.Update(p)
+}
+
+// RemoveProject unregisters a project without touching its files
+func (a *App) RemoveProject(id string) error {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return err
+	}
+	if err := registry.Remove(id); err != nil {
+		return err
+	}
+	a.unwatchProjectManifest(id)
+	return nil
+}
+
+// GetProjectManifestStatus validates the project's manifest against the installed versions,
+// listing missing versions together with their downloads so they can be installed
+func (a *App) GetProjectManifestStatus(projectID string) (*project.ManifestStatus, error) {
+	p, err := a.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := project.LoadManifest(p.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	status := manifest.Validate(config.LivePathsConfigManager())
+	status.Path = filepath.Join(p.Root, project.ManifestFileName)
+	return &status, nil
+}
+
+// CreateProjectTerminalSession creates a terminal session whose PATH uses the project's pinned versions
+func (a *App) CreateProjectTerminalSession(sessionID, terminalType, projectID string) error {
+	opts := utils.CreateSessionOptions{
+		SessionID:    sessionID,
+		TerminalType: terminalType,
+	}
+	if err := a.applyProjectSessionOptions(&opts, projectID); err != nil {
+		return err
+	}
+	return a.startTerminalSession(opts)
+}
+
+// applyProjectSessionOptions pins the project's service versions, adds its manifest environment
+// and starts the session in the project root unless another directory was requested
+func (a *App) applyProjectSessionOptions(opts *utils.CreateSessionOptions, projectID string) error {
+	p, err := a.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+
+	opts.ServicePins = p.Services
+	if opts.Dir == "" {
+		opts.Dir = p.Root
+	}
+	if manifest, err := project.LoadManifest(p.Root); err == nil && len(manifest.Env) > 0 {
+		env := make(map[string]string, len(manifest.Env)+len(opts.Env))
+		for key, value := range manifest.Env {
+			env[key] = value
+		}
+		for key, value := range opts.Env {
+			env[key] = value
+		}
+		opts.Env = env
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/events"
+	"github.com/JadlionHD/Enty/internal/project"
+)
+
+// newProjectTestApp returns an App with a project registry in a temporary directory
+func newProjectTestApp(t *testing.T) *App {
+	t.Helper()
+	manifestPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { manifestPollInterval = 2 * time.Second })
+
+	registry, err := project.NewRegistry(filepath.Join(t.TempDir(), "projects.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{bus: events.NewMemoryBus(), projects: registry, manifestWatchers: make(map[string]func())}
+	t.Cleanup(func() {
+		for _, p := range registry.List() {
+			a.unwatchProjectManifest(p.ID)
+		}
+	})
+	return a
+}
+
+func waitForServices(t *testing.T, a *App, projectID string, want map[string]string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p, err := a.GetProject(projectID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if maps.Equal(p.Services, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("services %v, want %v", p.Services, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDeletedManifestClearsPins(t *testing.T) {
+	a := newProjectTestApp(t)
+	root := t.TempDir()
+	manifest := filepath.Join(root, project.ManifestFileName)
+	os.WriteFile(manifest, []byte(`{"services": {"php": "8.3"}}`), 0o644)
+
+	added, err := a.AddProject(project.Project{Name: "shop", Root: root, Services: map[string]string{"node": "20"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForServices(t, a, added.ID, map[string]string{"node": "20", "php": "8.3"})
+
+	if err := os.Remove(manifest); err != nil {
+		t.Fatal(err)
+	}
+	waitForServices(t, a, added.ID, map[string]string{"node": "20"})
+}
+
+func TestManifestDeletedWhileClosedClearsPins(t *testing.T) {
+	a := newProjectTestApp(t)
+	root := t.TempDir()
+
+	// The registry still has the pins of a manifest that no longer exists
+	added, err := a.projects.Add(project.Project{
+		Name:             "shop",
+		Root:             root,
+		Services:         map[string]string{"node": "20", "php": "8.3"},
+		ManifestServices: map[string]string{"php": "8.3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.watchProjectManifest(added.ID, added.Root)
+	waitForServices(t, a, added.ID, map[string]string{"node": "20"})
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/asciicast"
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// RecordingInfo describes a terminal recording on disk
+type RecordingInfo struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Title     string    `json:"title"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Duration  float64   `json:"duration"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// recordingsDir returns the directory recordings are stored in
+func recordingsDir() (string, error) {
+	path, err := config.DataPath("recordings", "recording.cast")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(path), nil
+}
+
+// recordingPath resolves a recording name, rejecting anything outside the recordings directory
+func recordingPath(name string) (string, error) {
+	if name == "" || filepath.Base(name) != name || !strings.HasSuffix(name, ".cast") {
+		return "", fmt.Errorf("invalid recording name: %s", name)
+	}
+	dir, err := recordingsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// StartTerminalRecording records the output of a session in asciicast v2 format
+func (a *App) StartTerminalRecording(sessionID string) (*RecordingInfo, error) {
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now()
+	path, err := recordingPath(fmt.Sprintf("%s-%s.cast", sessionID, createdAt.Format("20060102-150405")))
+	if err != nil {
+		return nil, err
+	}
+
+	_, terminalType, _ := session.GetSessionInfo()
+	cols, rows := session.Size()
+	recorder, err := asciicast.Create(path, asciicast.Header{
+		Width:  cols,
+		Height: rows,
+		Title:  sessionID,
+		Env:    map[string]string{"SHELL": terminalType, "TERM": "xterm-256color"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.StartRecording(recorder); err != nil {
+		recorder.Close()
+		os.Remove(path)
+		return nil, err
+	}
+
+	return &RecordingInfo{
+		Name:      filepath.Base(path),
+		Path:      path,
+		Title:     sessionID,
+		Width:     cols,
+		Height:    rows,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// StopTerminalRecording stops recording a session
+func (a *App) StopTerminalRecording(sessionID string) error {
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	return session.StopRecording()
+}
+
+// IsTerminalRecording returns whether a session is being recorded
+func (a *App) IsTerminalRecording(sessionID string) bool {
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return false
+	}
+	return session.IsRecording()
+}
+
+// ListTerminalRecordings returns the saved recordings, newest first
+func (a *App) ListTerminalRecordings() ([]RecordingInfo, error) {
+	dir, err := recordingsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := []RecordingInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".cast") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		recording, err := asciicast.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, RecordingInfo{
+			Name:      entry.Name(),
+			Path:      path,
+			Title:     recording.Header.Title,
+			Width:     recording.Header.Width,
+			Height:    recording.Header.Height,
+			Duration:  recording.Duration(),
+			Size:      info.Size(),
+			CreatedAt: time.Unix(recording.Header.Timestamp, 0),
+		})
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].CreatedAt.After(recordings[j].CreatedAt)
+	})
+	return recordings, nil
+}
+
+// DeleteTerminalRecording removes a recording
+func (a *App) DeleteTerminalRecording(name string) error {
+	path, err := recordingPath(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// PlayTerminalRecording replays a recording as a read-only session stream and returns its ID.
+// Output arrives as terminal:data events, size changes as terminal:resize and the end as terminal:exit.
+func (a *App) PlayTerminalRecording(name string, speed float64) (string, error) {
+	path, err := recordingPath(name)
+	if err != nil {
+		return "", err
+	}
+	recording, err := asciicast.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	playbackID := "playback-" + hex.EncodeToString(buf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.playbackMutex.Lock()
+	if a.playbacks == nil {
+		a.playbacks = make(map[string]context.CancelFunc)
+	}
+	a.playbacks[playbackID] = cancel
+	a.playbackMutex.Unlock()
+
+	go func() {
+		defer func() {
+			a.playbackMutex.Lock()
+			delete(a.playbacks, playbackID)
+			a.playbackMutex.Unlock()
+			cancel()
+		}()
+
+		a.bus.Emit("terminal:resize", map[string]interface{}{
+			"sessionID": playbackID,
+			"cols":      recording.Header.Width,
+			"rows":      recording.Header.Height,
+		})
+
+		var offset int64
+		err := asciicast.Play(ctx, recording, asciicast.PlayOptions{
+			Speed:     speed,
+			IdleLimit: 2,
+			OnOutput: func(data string) {
+				offset += int64(len(data))
+				a.bus.Emit("terminal:data", map[string]interface{}{
+					"sessionID": playbackID,
+					"data":      data,
+					"offset":    offset,
+				})
+			},
+			OnResize: func(cols, rows int) {
+				a.bus.Emit("terminal:resize", map[string]interface{}{
+					"sessionID": playbackID,
+					"cols":      cols,
+					"rows":      rows,
+				})
+			},
+		})
+
+		message := "Playback finished"
+		if err != nil {
+			message = "Playback stopped"
+		}
+		a.bus.Emit("terminal:exit", map[string]interface{}{
+			"sessionID": playbackID,
+			"message":   message,
+		})
+	}()
+
+	return playbackID, nil
+}
+
+// StopTerminalPlayback cancels a running playback
+func (a *App) StopTerminalPlayback(playbackID string) error {
+	a.playbackMutex.Lock()
+	cancel, exists := a.playbacks[playbackID]
+	a.playbackMutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("playback %s not found", playbackID)
+	}
+	cancel()
+	return nil
+}
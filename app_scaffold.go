@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+
+	"github.com/JadlionHD/Enty/internal/project"
+	"github.com/JadlionHD/Enty/internal/scaffold"
+)
+
+// scaffoldEngine returns an engine bound to the project registry and hosts file
+func (a *App) scaffoldEngine() (*scaffold.Engine, error) {
+	registry, err := a.projectRegistry()
+	if err != nil {
+		return nil, err
+	}
+	return scaffold.NewEngine(registry, a.hostsManager), nil
+}
+
+// ListScaffoldTemplates returns the available project templates
+func (a *App) ListScaffoldTemplates() ([]scaffold.TemplateInfo, error) {
+	engine, err := a.scaffoldEngine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.Templates(), nil
+}
+
+// ScaffoldProject creates a new project from a template, emitting "scaffold:progress" events
+// while it runs, and registers it once finished
+func (a *App) ScaffoldProject(opts scaffold.Options) (*project.Project, error) {
+	engine, err := a.scaffoldEngine()
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make(chan scaffold.Progress, 16)
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for update := range progress {
+			a.bus.Emit("scaffold:progress", update)
+		}
+	}()
+
+	created, err := engine.Run(context.Background(), opts, progress)
+	<-forwarded
+	if err != nil {
+		return nil, err
+	}
+
+	a.watchProjectManifest(created.ID, created.Root)
+	return created, nil
+}
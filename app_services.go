@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/JadlionHD/Enty/internal/install"
+	"github.com/JadlionHD/Enty/internal/service"
+)
+
+// ListServiceStatuses returns the state of every service that can run in the background
+func (a *App) ListServiceStatuses() []*service.Status {
+	return service.List()
+}
+
+// StartService starts a background service. An empty version uses the active service path.
+func (a *App) StartService(name, version string) (*service.Status, error) {
+	return service.Start(name, version)
+}
+
+// StopService stops a background service
+func (a *App) StopService(name string) error {
+	return service.Stop(name)
+}
+
+// ListAvailableVersions returns the downloadable versions of a service, newest first
+func (a *App) ListAvailableVersions(name string) ([]string, error) {
+	return install.Available(name)
+}
+
+// InstallServiceVersion downloads, unpacks and registers a service version,
+// emitting "install:progress" events while downloading
+func (a *App) InstallServiceVersion(name, version string) (*install.Result, error) {
+	return install.Install(a.ctx, name, version, func(downloaded, total int64) {
+		a.bus.Emit("install:progress", map[string]interface{}{
+			"service":    name,
+			"version":    version,
+			"downloaded": downloaded,
+			"total":      total,
+		})
+	})
+}
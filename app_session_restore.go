@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// savedSessionsPath is where open sessions are written on exit
+func savedSessionsPath() (string, error) {
+	return config.DataPath("terminal-sessions.json")
+}
+
+// beforeClose saves the open sessions while the window is still up, before the UI closes its tabs
+func (a *App) beforeClose(ctx context.Context) bool {
+	a.saveTerminalSessions()
+	return false
+}
+
+// saveTerminalSessions writes the open sessions for the next launch, once per run
+func (a *App) saveTerminalSessions() {
+	a.saveSessionsOnce.Do(func() {
+		persist := config.LiveSettingsManager().Get().Terminal.Persist
+		if !persist.Enabled {
+			return
+		}
+		path, err := savedSessionsPath()
+		if err != nil {
+			log.Printf("Failed to save terminal sessions: %v", err)
+			return
+		}
+		if err := a.terminalManager.SaveSessions(path, persist.ScrollbackBytes); err != nil {
+			log.Printf("Failed to save terminal sessions: %v", err)
+		}
+	})
+}
+
+// GetRestorableSessions returns the sessions that were open when Enty last exited, without their output
+func (a *App) GetRestorableSessions() ([]utils.SavedSession, error) {
+	path, err := savedSessionsPath()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := utils.LoadSavedSessions(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]utils.SavedSession, 0, len(saved))
+	for _, s := range saved {
+		s.Scrollback = ""
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// RestoreTerminalSessions recreates saved sessions with their IDs, replaying their recent output,
+// and returns the IDs that were restored. An empty sessionIDs restores all of them. Startup commands
+// run again only when runStartupCommands is set. Restored sessions are removed from the saved list;
+// sessions that fail to restore stay in it.
+func (a *App) RestoreTerminalSessions(sessionIDs []string, runStartupCommands bool) ([]string, error) {
+	path, err := savedSessionsPath()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := utils.LoadSavedSessions(path)
+	if err != nil {
+		return nil, err
+	}
+
+	restored := []string{}
+	remaining := make([]utils.SavedSession, 0, len(saved))
+	var errs []error
+	for _, s := range saved {
+		if len(sessionIDs) > 0 && !slices.Contains(sessionIDs, s.SessionID) {
+			remaining = append(remaining, s)
+			continue
+		}
+		if err := a.startTerminalSession(s.ReplayOptions(runStartupCommands)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore session %s: %w", s.SessionID, err))
+			remaining = append(remaining, s)
+			continue
+		}
+		restored = append(restored, s.SessionID)
+	}
+
+	if err := utils.WriteSavedSessions(path, remaining); err != nil {
+		errs = append(errs, err)
+	}
+	return restored, errors.Join(errs...)
+}
+
+// DiscardRestorableSessions forgets the sessions saved on the last exit
+func (a *App) DiscardRestorableSessions() error {
+	path, err := savedSessionsPath()
+	if err != nil {
+		return err
+	}
+	return utils.WriteSavedSessions(path, nil)
+}
+
+// SetTerminalSessionTitle changes the name saved and shown for a session
+func (a *App) SetTerminalSessionTitle(sessionID, title string) error {
+	return a.terminalManager.SetSessionTitle(sessionID, title)
+}
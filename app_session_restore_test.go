@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/events"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+func TestRestoreKeepsFailedSessions(t *testing.T) {
+	t.Setenv(config.DataDirEnv, t.TempDir())
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	path, err := savedSessionsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := []utils.SavedSession{
+		{CreateSessionOptions: utils.CreateSessionOptions{SessionID: "fresh", TerminalType: "bash"}},
+		{CreateSessionOptions: utils.CreateSessionOptions{SessionID: "taken", TerminalType: "bash"}},
+	}
+	if err := utils.WriteSavedSessions(path, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := utils.NewTerminalManagerWithPTY(utils.NewFakePTYFactory())
+	t.Cleanup(manager.CleanupAll)
+	// A live session with the same ID makes the restore fail
+	if _, err := manager.CreateSession(utils.CreateSessionOptions{SessionID: "taken", TerminalType: "bash"}); err != nil {
+		t.Fatal(err)
+	}
+	a := &App{bus: events.NewMemoryBus(), terminalManager: manager}
+
+	restored, err := a.RestoreTerminalSessions(nil, false)
+	if err == nil {
+		t.Fatal("restoring a taken session ID succeeded")
+	}
+	if len(restored) != 1 || restored[0] != "fresh" {
+		t.Fatalf("restored %v, want [fresh]", restored)
+	}
+
+	remaining, err := utils.LoadSavedSessions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 1 || remaining[0].SessionID != "taken" {
+		t.Fatalf("saved sessions after restore %+v, want only taken", remaining)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/JadlionHD/Enty/internal/shim"
+)
+
+// regenerateShimsOnStartup refreshes the shims so they point at the running executable
+func (a *App) regenerateShimsOnStartup() {
+	if _, err := a.RegenerateShims(); err != nil {
+		log.Printf("Failed to generate shims: %v", err)
+	}
+}
+
+// GetShimsDirectory returns the directory to add to PATH so shells outside Enty use the shims
+func (a *App) GetShimsDirectory() (string, error) {
+	return shim.Dir()
+}
+
+// RegenerateShims rewrites the shim executables, e.g. after installing a new version.
+// It returns the names of the generated shims.
+func (a *App) RegenerateShims() ([]string, error) {
+	dir, err := shim.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate Enty executable: %w", err)
+	}
+
+	return shim.Generate(dir, exe)
+}
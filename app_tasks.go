@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/service"
+	"github.com/JadlionHD/Enty/internal/tasks"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// taskInterruptGrace is how long a task chain waits for the exit code after an interrupt
+var taskInterruptGrace = 2 * time.Second
+
+// taskStore loads the task store on first use
+func (a *App) taskStore() (*tasks.Store, error) {
+	a.tasksMutex.Lock()
+	defer a.tasksMutex.Unlock()
+
+	if a.tasks == nil {
+		path, err := config.DataPath("tasks.json")
+		if err != nil {
+			return nil, err
+		}
+		store, err := tasks.NewStore(path)
+		if err != nil {
+			return nil, err
+		}
+		a.tasks = store
+	}
+	return a.tasks, nil
+}
+
+// ListTasks returns the tasks of a project followed by the global tasks, with their last runs.
+// An empty projectID lists only global tasks.
+func (a *App) ListTasks(projectID string) ([]tasks.Info, error) {
+	store, err := a.taskStore()
+	if err != nil {
+		return nil, err
+	}
+	infos := store.List(projectID)
+	if infos == nil {
+		return []tasks.Info{}, nil
+	}
+	return infos, nil
+}
+
+// SaveTask adds or replaces a task of a project, or a global task when projectID is empty
+func (a *App) SaveTask(projectID string, task tasks.Task) error {
+	if projectID != "" {
+		if _, err := a.GetProject(projectID); err != nil {
+			return err
+		}
+	}
+	store, err := a.taskStore()
+	if err != nil {
+		return err
+	}
+	return store.Save(projectID, task)
+}
+
+// DeleteTask removes a task of a project, or a global task when projectID is empty
+func (a *App) DeleteTask(projectID, name string) error {
+	store, err := a.taskStore()
+	if err != nil {
+		return err
+	}
+	return store.Delete(projectID, name)
+}
+
+// RunTasks types the named tasks one after another into a terminal session, stopping at the first
+// failure, and returns the session ID. An empty sessionID opens a new session, in the project root
+// for project tasks. Progress is emitted as task:started and task:finished events.
+func (a *App) RunTasks(projectID string, names []string, sessionID string) (string, error) {
+	if len(names) == 0 {
+		return "", fmt.Errorf("no tasks given")
+	}
+	store, err := a.taskStore()
+	if err != nil {
+		return "", err
+	}
+
+	var root string
+	var pins map[string]string
+	if projectID != "" {
+		p, err := a.GetProject(projectID)
+		if err != nil {
+			return "", err
+		}
+		root, pins = p.Root, p.Services
+	}
+
+	chain := make([]*tasks.Info, 0, len(names))
+	for _, name := range names {
+		info, err := store.Get(projectID, name)
+		if err != nil {
+			return "", err
+		}
+		chain = append(chain, info)
+	}
+
+	if err := startTaskServices(chain, pins); err != nil {
+		return "", err
+	}
+
+	if sessionID == "" {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		sessionID = "task-" + hex.EncodeToString(buf)
+
+		opts := utils.CreateSessionOptions{SessionID: sessionID}
+		if projectID != "" {
+			if err := a.applyProjectSessionOptions(&opts, projectID); err != nil {
+				return "", err
+			}
+		}
+		if err := a.startTerminalSession(opts); err != nil {
+			return "", err
+		}
+	}
+	session, err := a.terminalManager.GetSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	go a.runTaskChain(store, session, sessionID, projectID, root, chain)
+	return sessionID, nil
+}
+
+// startTaskServices starts the services required by the tasks that Enty runs in the background,
+// using the project's pinned versions
+func startTaskServices(chain []*tasks.Info, pins map[string]string) error {
+	for _, info := range chain {
+		for _, name := range info.Services {
+			name = strings.ToLower(name)
+			if !slices.Contains(service.Supported(), name) || service.Get(name).Running {
+				continue
+			}
+			if _, err := service.Start(name, pins[name]); err != nil {
+				return fmt.Errorf("task %s requires %s: %w", info.Name, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runTaskChain runs the tasks in order, waiting for each to report its exit code
+func (a *App) runTaskChain(store *tasks.Store, session *utils.TerminalSession, sessionID, projectID, root string, chain []*tasks.Info) {
+	shell := session.Info().Shell
+
+	for _, info := range chain {
+		dir := info.Cwd
+		if root != "" && (dir == "" || !filepath.IsAbs(dir)) {
+			dir = filepath.Join(root, dir)
+		}
+
+		buf := make([]byte, 8)
+		rand.Read(buf)
+		token := hex.EncodeToString(buf)
+		line, reportsStatus := tasks.CommandLine(shell, info.Task, dir, token)
+
+		// Subscribe before typing so the marker cannot be missed
+		interrupted := session.Interrupted()
+		scanner := tasks.NewScanner(token)
+		codes := make(chan int, 1)
+		exited := make(chan struct{})
+		unsubscribe := session.Subscribe(func(data string) {
+			if code, found := scanner.Feed(data); found {
+				select {
+				case codes <- code:
+				default:
+				}
+			}
+		}, func(string) {
+			close(exited)
+		})
+
+		run := tasks.Run{Status: tasks.StatusRunning, StartedAt: time.Now(), SessionID: sessionID}
+		a.recordTaskRun(store, projectID, info.Name, run)
+
+		if err := session.Write(line + "\r"); err != nil {
+			unsubscribe()
+			run.Status = tasks.StatusCanceled
+			a.recordTaskRun(store, projectID, info.Name, run)
+			return
+		}
+
+		if !reportsStatus {
+			// The shell runs queued lines in order, but their exit codes are not known
+			unsubscribe()
+			run.Status = tasks.StatusUnknown
+			a.recordTaskRun(store, projectID, info.Name, run)
+			continue
+		}
+
+		// POSIX shells still print the marker after an interrupt, but other shells drop the rest of
+		// the line, so an interrupt without a marker soon after cancels the chain
+		var grace <-chan time.Time
+		for run.Status == tasks.StatusRunning {
+			select {
+			case code := <-codes:
+				run.ExitCode = &code
+				run.Status = tasks.StatusSucceeded
+				if code != 0 {
+					run.Status = tasks.StatusFailed
+				}
+			case <-exited:
+				run.Status = tasks.StatusCanceled
+			case <-interrupted:
+				interrupted = nil
+				grace = time.After(taskInterruptGrace)
+			case <-grace:
+				run.Status = tasks.StatusCanceled
+			}
+		}
+		unsubscribe()
+		run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+		a.recordTaskRun(store, projectID, info.Name, run)
+
+		if run.Status != tasks.StatusSucceeded {
+			return
+		}
+	}
+}
+
+// recordTaskRun stores a run and emits task:started or task:finished
+func (a *App) recordTaskRun(store *tasks.Store, projectID, name string, run tasks.Run) {
+	if err := store.RecordRun(projectID, name, run); err != nil {
+		log.Printf("Failed to record run of task %s: %v", name, err)
+	}
+
+	topic := "task:finished"
+	if run.Status == tasks.StatusRunning {
+		topic = "task:started"
+	}
+	a.bus.Emit(topic, map[string]interface{}{
+		"projectID": projectID,
+		"name":      name,
+		"run":       run,
+	})
+}
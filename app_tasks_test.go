@@ -0,0 +1,118 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/events"
+	"github.com/JadlionHD/Enty/internal/tasks"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+var taskTokenPattern = regexp.MustCompile(`enty-task;([0-9a-f]+);`)
+
+// startTaskChain runs the named global tasks in a bash session backed by a FakePTY
+func startTaskChain(t *testing.T, names ...string) (*tasks.Store, *utils.TerminalSession, *utils.FakePTY) {
+	t.Helper()
+	taskInterruptGrace = 20 * time.Millisecond
+	t.Cleanup(func() { taskInterruptGrace = 2 * time.Second })
+
+	store, err := tasks.NewStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chain []*tasks.Info
+	for _, name := range names {
+		if err := store.Save("", tasks.Task{Name: name, Command: "run-" + name}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := store.Get("", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, info)
+	}
+
+	factory := utils.NewFakePTYFactory()
+	manager := utils.NewTerminalManagerWithPTY(factory)
+	t.Cleanup(manager.CleanupAll)
+	session, err := manager.CreateSession(utils.CreateSessionOptions{SessionID: "tasks", TerminalType: "bash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatal(err)
+	}
+	session.StartReadLoop(nil, nil)
+
+	a := &App{bus: events.NewMemoryBus()}
+	go a.runTaskChain(store, session, "tasks", "", "", chain)
+	return store, session, factory.Last()
+}
+
+// typedToken waits for the task command to be typed and returns the token of its marker
+func typedToken(t *testing.T, pty *utils.FakePTY, command string) string {
+	t.Helper()
+	if !pty.WaitForInput(command, 5*time.Second) {
+		t.Fatalf("%s not typed; input %q", command, pty.Input())
+	}
+	matches := taskTokenPattern.FindAllStringSubmatch(pty.Input(), -1)
+	return matches[len(matches)-1][1]
+}
+
+func waitForRun(t *testing.T, store *tasks.Store, name, status string) *tasks.Run {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, err := store.Get("", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.LastRun != nil && info.LastRun.Status == status {
+			return info.LastRun
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s last run %+v, want %s", name, info.LastRun, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTaskChainReportsExitCodes(t *testing.T) {
+	store, _, pty := startTaskChain(t, "build", "test")
+
+	token := typedToken(t, pty, "run-build")
+	pty.Emit("built\r\n\x1b]777;enty-task;" + token + ";0\a")
+	if run := waitForRun(t, store, "build", tasks.StatusSucceeded); run.ExitCode == nil || *run.ExitCode != 0 {
+		t.Fatalf("build run %+v", run)
+	}
+
+	// The marker may arrive in pieces
+	token = typedToken(t, pty, "run-test")
+	pty.Emit("\x1b]777;enty-task;" + token[:4])
+	pty.Emit(token[4:] + ";130\a")
+	if run := waitForRun(t, store, "test", tasks.StatusFailed); run.ExitCode == nil || *run.ExitCode != 130 {
+		t.Fatalf("test run %+v", run)
+	}
+}
+
+func TestTaskChainInterruptWithoutMarkerCancels(t *testing.T) {
+	store, session, pty := startTaskChain(t, "serve", "after")
+
+	typedToken(t, pty, "run-serve")
+	// The shell drops the rest of the line, so no marker follows the interrupt
+	if err := session.Write("\x03"); err != nil {
+		t.Fatal(err)
+	}
+	waitForRun(t, store, "serve", tasks.StatusCanceled)
+
+	time.Sleep(50 * time.Millisecond)
+	if pty.WaitForInput("run-after", 0) {
+		t.Fatal("the chain continued after the interrupt")
+	}
+	if info, _ := store.Get("", "after"); info.LastRun != nil {
+		t.Fatalf("after ran: %+v", info.LastRun)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/termbridge"
+)
+
+// TerminalBridgeInfo tells clients where to attach to terminal sessions
+type TerminalBridgeInfo struct {
+	// URL is the WebSocket URL prefix; append the session ID
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// startTerminalBridgeIfEnabled starts the WebSocket terminal bridge when enabled in settings
+func (a *App) startTerminalBridgeIfEnabled() {
+	if !config.LiveSettingsManager().Get().Terminal.Bridge.Enabled {
+		return
+	}
+	if err := a.StartTerminalBridge(); err != nil {
+		log.Printf("Failed to start terminal bridge: %v", err)
+	}
+}
+
+// StartTerminalBridge starts the WebSocket endpoint that attaches to existing terminal sessions
+func (a *App) StartTerminalBridge() error {
+	a.bridgeMutex.Lock()
+	defer a.bridgeMutex.Unlock()
+
+	if a.terminalBridge != nil {
+		return nil
+	}
+
+	settings := config.LiveSettingsManager().Get().Terminal.Bridge
+	server, err := termbridge.NewServer(termbridge.ServerOptions{
+		Addr: net.JoinHostPort(settings.Address, strconv.Itoa(settings.Port)),
+		Lookup: func(sessionID string) (termbridge.Session, error) {
+			session, err := a.terminalManager.GetSession(sessionID)
+			if err != nil {
+				return nil, err
+			}
+			return session, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	a.terminalBridge = server
+	log.Printf("Terminal bridge listening on %s", server.Addr())
+	return nil
+}
+
+// StopTerminalBridge stops the WebSocket terminal bridge, disconnecting attached viewers
+func (a *App) StopTerminalBridge() error {
+	a.bridgeMutex.Lock()
+	defer a.bridgeMutex.Unlock()
+
+	if a.terminalBridge == nil {
+		return nil
+	}
+	err := a.terminalBridge.Stop()
+	a.terminalBridge = nil
+	return err
+}
+
+// GetTerminalBridgeInfo returns the bridge URL and token, or nil when the bridge is stopped
+func (a *App) GetTerminalBridgeInfo() *TerminalBridgeInfo {
+	a.bridgeMutex.Lock()
+	defer a.bridgeMutex.Unlock()
+
+	if a.terminalBridge == nil {
+		return nil
+	}
+	return &TerminalBridgeInfo{
+		URL:   "ws://" + a.terminalBridge.Addr() + termbridge.PathPrefix,
+		Token: a.terminalBridge.Token(),
+	}
+}
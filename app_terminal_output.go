@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/ansi"
+	"github.com/JadlionHD/Enty/internal/utils"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Transcript export formats
+const (
+	TranscriptText = "text"
+	TranscriptHTML = "html"
+)
+
+// SearchTerminalOutput returns the lines of a session's scrollback that contain pattern, with their
+// stream offsets. Plain patterns match case-insensitively; with regex set, pattern is a Go regular
+// expression. Sessions that ended recently can still be searched.
+func (a *App) SearchTerminalOutput(sessionID, pattern string, regex bool) ([]utils.OutputMatch, error) {
+	session, err := a.terminalManager.LookupSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return session.SearchOutput(pattern, regex)
+}
+
+// ExportTerminalOutput returns a session's transcript as plain text without escape sequences,
+// or as an HTML document that keeps the terminal colors
+func (a *App) ExportTerminalOutput(sessionID, format string) (string, error) {
+	session, err := a.terminalManager.LookupSession(sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	data, _ := session.Transcript()
+	switch format {
+	case TranscriptText, "":
+		return ansi.Strip(data), nil
+	case TranscriptHTML:
+		return ansi.HTML(data, "Terminal session "+sessionID), nil
+	default:
+		return "", fmt.Errorf("unknown transcript format: %s", format)
+	}
+}
+
+// SaveTerminalOutput asks for a file and writes a session's transcript to it in the given format.
+// It returns the path, or an empty string if the dialog was cancelled.
+func (a *App) SaveTerminalOutput(sessionID, format string) (string, error) {
+	content, err := a.ExportTerminalOutput(sessionID, format)
+	if err != nil {
+		return "", err
+	}
+
+	extension := ".txt"
+	if format == TranscriptHTML {
+		extension = ".html"
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export terminal output",
+		DefaultFilename: fmt.Sprintf("%s-%s%s", sessionID, time.Now().Format("20060102-150405"), extension),
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", fmt.Errorf("failed to save terminal output: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,12 @@
+// Command enty is the headless command line interface of Enty
+package main
+
+import (
+	"os"
+
+	"github.com/JadlionHD/Enty/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
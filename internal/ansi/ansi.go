@@ -0,0 +1,336 @@
+// Package ansi interprets terminal output: it removes escape sequences for plain text and
+// converts SGR colors and attributes to HTML.
+package ansi
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Style holds the SGR attributes of a run of text. Colors are CSS values; empty means the default.
+type Style struct {
+	Foreground string
+	Background string
+	Bold       bool
+	Dim        bool
+	Italic     bool
+	Underline  bool
+	Inverse    bool
+}
+
+// Run is text printed with one style
+type Run struct {
+	Style Style
+	Text  string
+}
+
+// Line is one line of output as it appears on screen
+type Line []Run
+
+// Text returns the line without styling
+func (l Line) Text() string {
+	var b strings.Builder
+	for _, run := range l {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+// Parse splits output into lines of styled text. Carriage returns and backspaces move the cursor
+// as on a terminal, so later text overwrites the line in place, as progress bars do. Erasing within
+// the line and horizontal cursor movement are applied; other escape sequences except SGR are dropped.
+func Parse(output string) []Line {
+	p := parser{}
+	for i := 0; i < len(output); {
+		c := output[i]
+		switch {
+		case c == 0x1b:
+			i = p.escape(output, i)
+			continue
+		case c == '\n':
+			p.newline()
+		case c == '\r':
+			// "\r\n" ends the line; a lone "\r" returns to its start
+			if i+1 >= len(output) || output[i+1] != '\n' {
+				p.cursor = 0
+			}
+		case c == '\b':
+			p.cursor = max(p.cursor-1, 0)
+		case c == '\t':
+			p.put("\t")
+		case c < 0x20 || c == 0x7f:
+			// Other control characters, such as BEL, do not print
+		default:
+			// Invalid UTF-8 is kept byte by byte
+			_, size := utf8.DecodeRuneInString(output[i:])
+			p.put(output[i : i+size])
+			i += size
+			continue
+		}
+		i++
+	}
+	if len(p.cells) > 0 {
+		p.lines = append(p.lines, p.line())
+	}
+	return p.lines
+}
+
+// Strip returns output as plain text without escape sequences
+func Strip(output string) string {
+	lines := Parse(output)
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		texts[i] = line.Text()
+	}
+	text := strings.Join(texts, "\n")
+	if strings.HasSuffix(output, "\n") {
+		text += "\n"
+	}
+	return text
+}
+
+// cell is one character on the current line
+type cell struct {
+	text  string
+	style Style
+}
+
+type parser struct {
+	lines  []Line
+	cells  []cell
+	cursor int
+	style  Style
+}
+
+// put writes a character at the cursor, overwriting what is there
+func (p *parser) put(text string) {
+	for len(p.cells) < p.cursor {
+		p.cells = append(p.cells, cell{text: " "})
+	}
+	if p.cursor < len(p.cells) {
+		p.cells[p.cursor] = cell{text: text, style: p.style}
+	} else {
+		p.cells = append(p.cells, cell{text: text, style: p.style})
+	}
+	p.cursor++
+}
+
+func (p *parser) newline() {
+	p.lines = append(p.lines, p.line())
+	p.cells, p.cursor = nil, 0
+}
+
+// line joins the cells of the current line into runs of the same style
+func (p *parser) line() Line {
+	var line Line
+	var text strings.Builder
+	for i, c := range p.cells {
+		if i > 0 && c.style != p.cells[i-1].style {
+			line = append(line, Run{Style: p.cells[i-1].style, Text: text.String()})
+			text.Reset()
+		}
+		text.WriteString(c.text)
+	}
+	if text.Len() > 0 {
+		line = append(line, Run{Style: p.cells[len(p.cells)-1].style, Text: text.String()})
+	}
+	return line
+}
+
+// csi applies a control sequence that changes the current line: erase in line (K) and cursor
+// forward (C), back (D) and to a column (G)
+func (p *parser) csi(params string, final byte) {
+	n, err := strconv.Atoi(params)
+	if err != nil {
+		n = 0
+	}
+	switch final {
+	case 'K':
+		switch n {
+		case 0:
+			if p.cursor < len(p.cells) {
+				p.cells = p.cells[:p.cursor]
+			}
+		case 1:
+			for i := 0; i <= p.cursor && i < len(p.cells); i++ {
+				p.cells[i] = cell{text: " "}
+			}
+		case 2:
+			p.cells = nil
+		}
+	case 'C':
+		p.cursor += max(n, 1)
+	case 'D':
+		p.cursor = max(p.cursor-max(n, 1), 0)
+	case 'G':
+		p.cursor = max(n, 1) - 1
+	}
+}
+
+// escape consumes the escape sequence at output[i] and returns the index after it
+func (p *parser) escape(output string, i int) int {
+	if i+1 >= len(output) {
+		return len(output)
+	}
+	switch output[i+1] {
+	case '[':
+		// CSI: parameters and intermediates up to a final byte in 0x40-0x7e
+		end := i + 2
+		for end < len(output) && (output[end] < 0x40 || output[end] > 0x7e) {
+			end++
+		}
+		if end >= len(output) {
+			return len(output)
+		}
+		if output[end] == 'm' {
+			p.sgr(output[i+2 : end])
+		} else {
+			p.csi(output[i+2:end], output[end])
+		}
+		return end + 1
+	case ']', 'P', '_', '^':
+		// OSC and other strings end with BEL or ST (ESC \)
+		for end := i + 2; end < len(output); end++ {
+			if output[end] == 0x07 {
+				return end + 1
+			}
+			if output[end] == 0x1b && end+1 < len(output) && output[end+1] == '\\' {
+				return end + 2
+			}
+		}
+		return len(output)
+	case '(', ')', '*', '+':
+		// Character set designation
+		return i + 3
+	default:
+		return i + 2
+	}
+}
+
+// sgr applies Select Graphic Rendition parameters
+func (p *parser) sgr(params string) {
+	if params == "" {
+		p.style = Style{}
+		return
+	}
+
+	codes := strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ':' })
+	for i := 0; i < len(codes); i++ {
+		code, err := strconv.Atoi(codes[i])
+		if err != nil {
+			continue
+		}
+		switch {
+		case code == 0:
+			p.style = Style{}
+		case code == 1:
+			p.style.Bold = true
+		case code == 2:
+			p.style.Dim = true
+		case code == 3:
+			p.style.Italic = true
+		case code == 4:
+			p.style.Underline = true
+		case code == 7:
+			p.style.Inverse = true
+		case code == 22:
+			p.style.Bold, p.style.Dim = false, false
+		case code == 23:
+			p.style.Italic = false
+		case code == 24:
+			p.style.Underline = false
+		case code == 27:
+			p.style.Inverse = false
+		case code >= 30 && code <= 37:
+			p.style.Foreground = palette[code-30]
+		case code >= 90 && code <= 97:
+			p.style.Foreground = palette[code-90+8]
+		case code == 39:
+			p.style.Foreground = ""
+		case code >= 40 && code <= 47:
+			p.style.Background = palette[code-40]
+		case code >= 100 && code <= 107:
+			p.style.Background = palette[code-100+8]
+		case code == 49:
+			p.style.Background = ""
+		case code == 38 || code == 48:
+			color, consumed := extendedColor(codes[i+1:])
+			i += consumed
+			if color == "" {
+				continue
+			}
+			if code == 38 {
+				p.style.Foreground = color
+			} else {
+				p.style.Background = color
+			}
+		}
+	}
+}
+
+// extendedColor parses "5;n" and "2;r;g;b" after 38 or 48, returning the color and the number of parameters used
+func extendedColor(params []string) (string, int) {
+	if len(params) == 0 {
+		return "", 0
+	}
+	values := make([]int, 0, 4)
+	for _, param := range params {
+		value, _ := strconv.Atoi(param)
+		values = append(values, value)
+	}
+
+	switch values[0] {
+	case 5:
+		if len(values) < 2 {
+			return "", len(values)
+		}
+		return color256(values[1]), 2
+	case 2:
+		if len(values) < 4 {
+			return "", len(values)
+		}
+		return rgb(values[1], values[2], values[3]), 4
+	default:
+		return "", 1
+	}
+}
+
+// palette is the xterm default for the 16 basic colors
+var palette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// color256 converts an xterm 256-color index
+func color256(index int) string {
+	switch {
+	case index < 0 || index > 255:
+		return ""
+	case index < 16:
+		return palette[index]
+	case index < 232:
+		// 6x6x6 color cube
+		index -= 16
+		level := func(v int) int {
+			if v == 0 {
+				return 0
+			}
+			return 55 + v*40
+		}
+		return rgb(level(index/36), level(index/6%6), level(index%6))
+	default:
+		gray := 8 + (index-232)*10
+		return rgb(gray, gray, gray)
+	}
+}
+
+func rgb(r, g, b int) string {
+	clamp := func(v int) int { return min(max(v, 0), 255) }
+	return "#" + hexByte(clamp(r)) + hexByte(clamp(g)) + hexByte(clamp(b))
+}
+
+func hexByte(v int) string {
+	const digits = "0123456789abcdef"
+	return string([]byte{digits[v>>4], digits[v&0x0f]})
+}
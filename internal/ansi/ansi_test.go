@@ -0,0 +1,76 @@
+package ansi
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{"plain", "hello\nworld\n", "hello\nworld\n"},
+		{"crlf", "a\r\nb\r\n", "a\nb\n"},
+		{"carriage return overwrites", "abc\rX", "Xbc"},
+		{"progress", "loading 10%\rloading 100%\r\n", "loading 100%\n"},
+		{"progress with erase", "downloading 50%\r\x1b[Kdone\n", "done\n"},
+		{"backspace moves left", "abc\b\bX", "aXc"},
+		{"backspace and erase", "abc\b\x1b[K", "ab"},
+		{"erase whole line", "abc\x1b[2Kx", "   x"},
+		{"erase to cursor", "abcd\b\b\x1b[1K", "   d"},
+		{"cursor movement", "ab\x1b[3Cc\x1b[1Gx\x1b[2Dy", "yb   c"},
+		{"multibyte overwrite", "café\ré", "éafé"},
+		{"invalid utf-8", "a\xffb", "a\xffb"},
+		{"tab", "a\tb", "a\tb"},
+		{"sgr", "\x1b[1;31mred\x1b[0m plain", "red plain"},
+		{"osc title", "\x1b]0;title\x07text\x1b]2;other\x1b\\!", "text!"},
+		{"private modes", "\x1b[?2004h$ ls\x1b[?2004l\r\n", "$ ls\n"},
+		{"charset", "\x1b(Bok", "ok"},
+		{"bell", "done\a\n", "done\n"},
+		{"truncated escape", "text\x1b[3", "text"},
+	}
+	for _, tc := range cases {
+		if got := Strip(tc.output); got != tc.want {
+			t.Errorf("%s: Strip(%q) = %q, want %q", tc.name, tc.output, got, tc.want)
+		}
+	}
+}
+
+func TestParseStyles(t *testing.T) {
+	red := Style{Foreground: palette[1]}
+	cases := []struct {
+		name   string
+		output string
+		want   Line
+	}{
+		{"reset", "\x1b[31ma\x1b[0mb", Line{{red, "a"}, {Style{}, "b"}}},
+		{"empty reset", "\x1b[31ma\x1b[mb", Line{{red, "a"}, {Style{}, "b"}}},
+		{"attributes", "\x1b[1;2;3;4;7mx", Line{{Style{Bold: true, Dim: true, Italic: true, Underline: true, Inverse: true}, "x"}}},
+		{"attributes off", "\x1b[1;3;4;7m\x1b[22;23;24;27mx", Line{{Style{}, "x"}}},
+		{"bright", "\x1b[94;101mx", Line{{Style{Foreground: "#5c5cff", Background: "#ff0000"}, "x"}}},
+		{"default colors", "\x1b[31;41m\x1b[39;49mx", Line{{Style{}, "x"}}},
+		{"256 colors", "\x1b[38;5;196;48;5;244mx", Line{{Style{Foreground: "#ff0000", Background: "#808080"}, "x"}}},
+		{"256 cube", "\x1b[38;5;110mx", Line{{Style{Foreground: "#87afd7"}, "x"}}},
+		{"truecolor", "\x1b[38;2;1;2;3mx", Line{{Style{Foreground: "#010203"}, "x"}}},
+		{"colon separators", "\x1b[38:2:255:128:0mx", Line{{Style{Foreground: "#ff8000"}, "x"}}},
+		{"out of range", "\x1b[38;5;300mx", Line{{Style{}, "x"}}},
+		{"overwrite keeps new style", "ab\r\x1b[31mX", Line{{red, "X"}, {Style{}, "b"}}},
+	}
+	for _, tc := range cases {
+		lines := Parse(tc.output)
+		if len(lines) != 1 || !equalLines(lines[0], tc.want) {
+			t.Errorf("%s: Parse(%q) = %+v, want %+v", tc.name, tc.output, lines, tc.want)
+		}
+	}
+}
+
+func equalLines(a, b Line) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
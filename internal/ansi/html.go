@@ -0,0 +1,74 @@
+package ansi
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	defaultForeground = "#e5e5e5"
+	defaultBackground = "#1e1e1e"
+)
+
+// HTML renders output as a standalone HTML document, keeping its colors and attributes
+func HTML(output, title string) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title>\n<style>\nbody { margin: 0; background: " + defaultBackground + "; }\n")
+	b.WriteString("pre { margin: 0; padding: 16px; color: " + defaultForeground + "; background: " + defaultBackground +
+		"; font-family: Menlo, Consolas, \"DejaVu Sans Mono\", monospace; font-size: 13px; white-space: pre-wrap; }\n")
+	b.WriteString("</style>\n</head>\n<body>\n<pre>")
+
+	for i, line := range Parse(output) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, run := range line {
+			text := html.EscapeString(run.Text)
+			if css := run.Style.css(); css != "" {
+				b.WriteString(`<span style="` + css + `">` + text + "</span>")
+			} else {
+				b.WriteString(text)
+			}
+		}
+	}
+
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// css returns the inline style of a run, or an empty string for default text
+func (s Style) css() string {
+	foreground, background := s.Foreground, s.Background
+	if s.Inverse {
+		if foreground == "" {
+			foreground = defaultForeground
+		}
+		if background == "" {
+			background = defaultBackground
+		}
+		foreground, background = background, foreground
+	}
+
+	var rules []string
+	if foreground != "" {
+		rules = append(rules, "color: "+foreground)
+	}
+	if background != "" {
+		rules = append(rules, "background: "+background)
+	}
+	if s.Bold {
+		rules = append(rules, "font-weight: bold")
+	}
+	if s.Dim {
+		rules = append(rules, "opacity: 0.7")
+	}
+	if s.Italic {
+		rules = append(rules, "font-style: italic")
+	}
+	if s.Underline {
+		rules = append(rules, "text-decoration: underline")
+	}
+	return strings.Join(rules, "; ")
+}
@@ -0,0 +1,34 @@
+package ansi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML(t *testing.T) {
+	cases := []struct {
+		output string
+		want   string
+	}{
+		{"plain <b>&</b>", "plain &lt;b&gt;&amp;&lt;/b&gt;"},
+		{"\x1b[1;31mred\x1b[0m ok", `<span style="color: #cd0000; font-weight: bold">red</span> ok`},
+		{"\x1b[2;3;4mx", `<span style="opacity: 0.7; font-style: italic; text-decoration: underline">x</span>`},
+		{"\x1b[38;5;196;48;2;0;0;255mx", `<span style="color: #ff0000; background: #0000ff">x</span>`},
+		{"\x1b[7mx", `<span style="color: #1e1e1e; background: #e5e5e5">x</span>`},
+		{"\x1b[7;32mx", `<span style="color: #1e1e1e; background: #00cd00">x</span>`},
+		{"a\r\n\x1b[33mb\x1b[0m\n", "a\n" + `<span style="color: #cdcd00">b</span>`},
+		{"50%\r\x1b[32mdone\x1b[0m", `<span style="color: #00cd00">done</span>`},
+	}
+	for _, tc := range cases {
+		document := HTML(tc.output, "title")
+		start := strings.Index(document, "<pre>") + len("<pre>")
+		end := strings.Index(document, "</pre>")
+		if got := document[start:end]; got != tc.want {
+			t.Errorf("HTML(%q):\ngot  %s\nwant %s", tc.output, got, tc.want)
+		}
+	}
+
+	if document := HTML("", `<script>"x"</script>`); !strings.Contains(document, "<title>&lt;script&gt;&#34;x&#34;&lt;/script&gt;</title>") {
+		t.Fatalf("title not escaped:\n%s", document)
+	}
+}
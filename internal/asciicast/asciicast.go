@@ -0,0 +1,241 @@
+// Package asciicast reads and writes terminal recordings in asciinema's asciicast v2 format:
+// a JSON header line followed by one [time, code, data] event per line.
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event codes
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+	EventMarker = "m"
+)
+
+// Header is the first line of a recording
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Duration  float64           `json:"duration,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a single timestamped entry; Time is in seconds since the start of the recording
+type Event struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// MarshalJSON encodes the event as a [time, code, data] array
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Code, e.Data})
+}
+
+// UnmarshalJSON decodes a [time, code, data] array
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("event has %d fields, expected 3", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw[1], &e.Code); err != nil {
+		return err
+	}
+	return json.Unmarshal(raw[2], &e.Data)
+}
+
+// Size parses the "COLSxROWS" data of a resize event
+func (e Event) Size() (cols, rows int, ok bool) {
+	colsText, rowsText, found := strings.Cut(e.Data, "x")
+	if !found {
+		return 0, 0, false
+	}
+	cols, errCols := strconv.Atoi(colsText)
+	rows, errRows := strconv.Atoi(rowsText)
+	return cols, rows, errCols == nil && errRows == nil
+}
+
+// flushDelay is how long recorded events may stay buffered before they are written to the file
+var flushDelay = time.Second
+
+// Recorder appends events to a recording file. It is safe for concurrent use.
+type Recorder struct {
+	mutex      sync.Mutex
+	file       *os.File
+	writer     *bufio.Writer
+	start      time.Time
+	flushTimer *time.Timer
+	closed     bool
+}
+
+// Create starts a new recording at path with the given header. Version and Timestamp are filled in.
+func Create(path string, header Header) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	start := time.Now()
+	header.Version = 2
+	header.Timestamp = start.Unix()
+
+	line, err := json.Marshal(header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	r := &Recorder{file: file, writer: bufio.NewWriter(file), start: start}
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Output records terminal output
+func (r *Recorder) Output(data string) error {
+	return r.write(EventOutput, data)
+}
+
+// Resize records a terminal size change
+func (r *Recorder) Resize(cols, rows int) error {
+	return r.write(EventResize, fmt.Sprintf("%dx%d", cols, rows))
+}
+
+// Close flushes and closes the recording
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.flushTimer != nil {
+		r.flushTimer.Stop()
+	}
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+func (r *Recorder) write(code, data string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("recording is closed")
+	}
+
+	elapsed := time.Since(r.start).Seconds()
+	line, err := json.Marshal(Event{Time: float64(int64(elapsed*1e6)) / 1e6, Code: code, Data: data})
+	if err != nil {
+		return err
+	}
+	if _, err := r.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	// Flush at most flushDelay after an event so a crash loses little, without a syscall for
+	// every keystroke echo; large bursts are flushed right away
+	if r.writer.Buffered() > 16*1024 {
+		return r.writer.Flush()
+	}
+	if r.flushTimer == nil {
+		r.flushTimer = time.AfterFunc(flushDelay, r.flush)
+	}
+	return nil
+}
+
+// flush writes buffered events once the flush delay has passed
+func (r *Recorder) flush() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.flushTimer = nil
+	if !r.closed {
+		r.writer.Flush()
+	}
+}
+
+// Recording is a parsed recording
+type Recording struct {
+	Header Header
+	Events []Event
+}
+
+// Duration returns the time of the last event, or the header duration when present
+func (r *Recording) Duration() float64 {
+	if r.Header.Duration > 0 {
+		return r.Header.Duration
+	}
+	if len(r.Events) == 0 {
+		return 0
+	}
+	return r.Events[len(r.Events)-1].Time
+}
+
+// Read parses a recording
+func Read(reader io.Reader) (*Recording, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("recording is empty")
+	}
+
+	recording := &Recording{}
+	if err := json.Unmarshal(scanner.Bytes(), &recording.Header); err != nil {
+		return nil, fmt.Errorf("invalid recording header: %w", err)
+	}
+	if recording.Header.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", recording.Header.Version)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, fmt.Errorf("invalid event on line %d: %w", line, err)
+		}
+		recording.Events = append(recording.Events, event)
+	}
+	return recording, scanner.Err()
+}
+
+// ReadFile parses the recording at path
+func ReadFile(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Read(file)
+}
@@ -0,0 +1,122 @@
+package asciicast
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.cast")
+	recorder, err := Create(path, Header{Width: 80, Height: 24, Title: "bash", Env: map[string]string{"TERM": "xterm-256color"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := []string{"$ ls\r\n", "café \"quoted\" \x1b[31mred\x1b[0m\r\n", ""}
+	for _, data := range outputs[:2] {
+		if err := recorder.Output(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Resize(120, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Output(outputs[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Output("late"); err == nil {
+		t.Fatal("write after Close succeeded")
+	}
+
+	recording, err := ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := recording.Header
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Title != "bash" ||
+		header.Env["TERM"] != "xterm-256color" || header.Timestamp == 0 {
+		t.Fatalf("header %+v", header)
+	}
+
+	want := []Event{
+		{Code: EventOutput, Data: outputs[0]},
+		{Code: EventOutput, Data: outputs[1]},
+		{Code: EventResize, Data: "120x40"},
+		{Code: EventOutput, Data: outputs[2]},
+	}
+	if len(recording.Events) != len(want) {
+		t.Fatalf("events %+v", recording.Events)
+	}
+	previous := 0.0
+	for i, event := range recording.Events {
+		if event.Code != want[i].Code || event.Data != want[i].Data {
+			t.Fatalf("event %d = %+v, want %+v", i, event, want[i])
+		}
+		if event.Time < previous {
+			t.Fatalf("event %d at %v goes back in time", i, event.Time)
+		}
+		previous = event.Time
+	}
+	if cols, rows, ok := recording.Events[2].Size(); !ok || cols != 120 || rows != 40 {
+		t.Fatalf("resize size %d, %d, %v", cols, rows, ok)
+	}
+	if recording.Duration() != previous {
+		t.Fatalf("duration %v, want %v", recording.Duration(), previous)
+	}
+}
+
+func TestRecorderFlushesAfterDelay(t *testing.T) {
+	flushDelay = 10 * time.Millisecond
+	defer func() { flushDelay = time.Second }()
+
+	path := filepath.Join(t.TempDir(), "session.cast")
+	recorder, err := Create(path, Header{Width: 80, Height: 24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+	recorder.Output("prompt$ ")
+
+	// The event reaches the file while the recording is still open
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), `"prompt$ "`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("event not flushed:\n%s", data)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReadRejectsInvalidRecordings(t *testing.T) {
+	cases := map[string]string{
+		"empty":       "",
+		"version":     `{"version":1,"width":80,"height":24}` + "\n",
+		"header":      "not json\n",
+		"event":       `{"version":2,"width":80,"height":24}` + "\n" + `[0.5, "o"]` + "\n",
+		"event field": `{"version":2,"width":80,"height":24}` + "\n" + `["soon", "o", "x"]` + "\n",
+	}
+	for name, input := range cases {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: invalid recording accepted", name)
+		}
+	}
+
+	// Blank lines are skipped and the header duration wins over the last event
+	recording, err := Read(strings.NewReader(`{"version":2,"width":80,"height":24,"duration":9.5}` + "\n\n" + `[1.25, "o", "x"]` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Events) != 1 || recording.Events[0].Time != 1.25 || recording.Duration() != 9.5 {
+		t.Fatalf("recording %+v", recording)
+	}
+}
@@ -0,0 +1,63 @@
+package asciicast
+
+import (
+	"context"
+	"time"
+)
+
+// PlayOptions controls playback
+type PlayOptions struct {
+	// Speed multiplies the playback rate; values <= 0 mean 1
+	Speed float64
+	// IdleLimit caps pauses between events, in seconds; 0 keeps the recorded timing
+	IdleLimit float64
+	// OnOutput receives output events
+	OnOutput func(data string)
+	// OnResize receives resize events
+	OnResize func(cols, rows int)
+}
+
+// Play replays a recording with its original timing, scaled by opts.Speed.
+// It returns ctx.Err() when cancelled and nil once every event has been delivered.
+func Play(ctx context.Context, recording *Recording, opts PlayOptions) error {
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	previous := 0.0
+	for _, event := range recording.Events {
+		delay := event.Time - previous
+		if opts.IdleLimit > 0 && delay > opts.IdleLimit {
+			delay = opts.IdleLimit
+		}
+		previous = event.Time
+
+		if delay > 0 {
+			timer.Reset(time.Duration(delay / speed * float64(time.Second)))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		switch event.Code {
+		case EventOutput:
+			if opts.OnOutput != nil {
+				opts.OnOutput(event.Data)
+			}
+		case EventResize:
+			if cols, rows, ok := event.Size(); ok && opts.OnResize != nil {
+				opts.OnResize(cols, rows)
+			}
+		}
+	}
+	return nil
+}
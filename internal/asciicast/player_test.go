@@ -0,0 +1,76 @@
+package asciicast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlaySpeedAndIdleLimit(t *testing.T) {
+	recording := &Recording{Events: []Event{
+		{Time: 0.2, Code: EventOutput, Data: "a"},
+		{Time: 0.2, Code: EventResize, Data: "100x30"},
+		{Time: 0.4, Code: EventInput, Data: "ignored"},
+		// A long pause that the idle limit cuts short
+		{Time: 60, Code: EventOutput, Data: "b"},
+		{Time: 60.2, Code: EventMarker, Data: "chapter"},
+		{Time: 60.4, Code: EventOutput, Data: "c"},
+	}}
+
+	var output strings.Builder
+	var sizes []string
+	start := time.Now()
+	err := Play(context.Background(), recording, PlayOptions{
+		Speed:     4,
+		IdleLimit: 0.2,
+		OnOutput:  func(data string) { output.WriteString(data) },
+		OnResize:  func(cols, rows int) { sizes = append(sizes, fmt.Sprintf("%dx%d", cols, rows)) },
+	})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != "abc" {
+		t.Fatalf("output %q, want abc", output.String())
+	}
+	if len(sizes) != 1 || sizes[0] != "100x30" {
+		t.Fatalf("resizes %v", sizes)
+	}
+	// Five gaps of 0.2s at four times the speed take 0.25s
+	if elapsed < 200*time.Millisecond || elapsed > 700*time.Millisecond {
+		t.Fatalf("playback took %v, want about 250ms", elapsed)
+	}
+}
+
+func TestPlayCancel(t *testing.T) {
+	recording := &Recording{Events: []Event{
+		{Time: 0, Code: EventOutput, Data: "first"},
+		{Time: 30, Code: EventOutput, Data: "never"},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var output []string
+	done := make(chan error, 1)
+	go func() {
+		done <- Play(ctx, recording, PlayOptions{OnOutput: func(data string) {
+			output = append(output, data)
+			cancel()
+		}})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Play = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Play did not stop when cancelled")
+	}
+	if len(output) != 1 || output[0] != "first" {
+		t.Fatalf("output %v", output)
+	}
+}
@@ -0,0 +1,191 @@
+// Package certs provides a local certificate authority for issuing development HTTPS certificates.
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	caCertFile = "ca.pem"
+	caKeyFile  = "ca-key.pem"
+	sitesDir   = "sites"
+
+	caValidity = 10 * 365 * 24 * time.Hour
+)
+
+// Authority is a local root CA that issues leaf certificates for development hostnames
+type Authority struct {
+	dir    string
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	leaves map[string]*tls.Certificate
+	mutex  sync.RWMutex
+}
+
+// NewAuthority loads the root CA from dir, generating a new one if none exists yet
+func NewAuthority(dir string) (*Authority, error) {
+	if err := os.MkdirAll(filepath.Join(dir, sitesDir), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+
+	ca := &Authority{
+		dir:    dir,
+		leaves: make(map[string]*tls.Certificate),
+	}
+
+	if _, err := os.Stat(ca.CACertPath()); os.IsNotExist(err) {
+		if err := ca.generateRoot(); err != nil {
+			return nil, err
+		}
+		return ca, nil
+	}
+
+	if err := ca.loadRoot(); err != nil {
+		return nil, err
+	}
+	return ca, nil
+}
+
+// CACertPath returns the path of the root certificate so users can add it to their trust store
+func (ca *Authority) CACertPath() string {
+	return filepath.Join(ca.dir, caCertFile)
+}
+
+// CAKeyPath returns the path of the root private key
+func (ca *Authority) CAKeyPath() string {
+	return filepath.Join(ca.dir, caKeyFile)
+}
+
+// CAExpiry returns when the root certificate expires
+func (ca *Authority) CAExpiry() time.Time {
+	ca.mutex.RLock()
+	defer ca.mutex.RUnlock()
+	return ca.caCert.NotAfter
+}
+
+// generateRoot creates a new self-signed root certificate and writes it to disk
+func (ca *Authority) generateRoot() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"Enty Development CA"},
+			OrganizationalUnit: []string{hostname},
+			CommonName:         "Enty Local Root CA",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	if err := writeKey(ca.CAKeyPath(), key); err != nil {
+		return err
+	}
+	if err := writeCert(ca.CACertPath(), der); err != nil {
+		return err
+	}
+
+	ca.caCert = cert
+	ca.caKey = key
+	return nil
+}
+
+// loadRoot reads the root certificate and key from disk
+func (ca *Authority) loadRoot() error {
+	certPEM, err := os.ReadFile(ca.CACertPath())
+	if err != nil {
+		return fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(ca.CAKeyPath())
+	if err != nil {
+		return fmt.Errorf("failed to read CA key: %w", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return fmt.Errorf("invalid CA certificate: %s", ca.CACertPath())
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return fmt.Errorf("invalid CA key: %s", ca.CAKeyPath())
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse CA key: %w", err)
+	}
+
+	ca.caCert = cert
+	ca.caKey = key
+	return nil
+}
+
+// writeCert writes a DER certificate as PEM
+func writeCert(path string, der []byte) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+	return nil
+}
+
+// writeKey writes a private key as PEM readable only by the current user
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	return nil
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
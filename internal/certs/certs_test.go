@@ -0,0 +1,188 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestAuthority(t *testing.T) (*Authority, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ca, err := NewAuthority(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca, dir
+}
+
+// writeLeaf signs a leaf for hostname that expires at notAfter and writes it where issue would
+func writeLeaf(t *testing.T, ca *Authority, hostname string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := randomSerial()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hostname},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     []string{hostname},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.caCert, &key.PublicKey, ca.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeKey(ca.leafKeyPath(hostname), key); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeCert(ca.leafCertPath(hostname), der); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIssueSite(t *testing.T) {
+	ca, dir := newTestAuthority(t)
+
+	site, err := ca.IssueSite("Shop.Test.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if site.Hostname != "shop.test" || site.CertPath != filepath.Join(dir, sitesDir, "shop.test.pem") {
+		t.Fatalf("site %+v", site)
+	}
+	if _, err := os.Stat(site.KeyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := ca.Certificate("shop.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.caCert)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{DNSName: "shop.test", Roots: roots}); err != nil {
+		t.Fatalf("leaf does not verify against the root: %v", err)
+	}
+	if d := time.Until(site.NotAfter); d < leafValidity-time.Hour || d > leafValidity {
+		t.Fatalf("leaf valid for %v", d)
+	}
+
+	ip, err := ca.Certificate("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ip.Leaf.IPAddresses) != 1 || len(ip.Leaf.DNSNames) != 0 {
+		t.Fatalf("IP leaf has SANs %v %v", ip.Leaf.IPAddresses, ip.Leaf.DNSNames)
+	}
+}
+
+func TestReloadFromDisk(t *testing.T) {
+	ca, dir := newTestAuthority(t)
+	first, err := ca.Certificate("app.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new Authority loads the same root and leaf instead of issuing new ones
+	reloaded, err := NewAuthority(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.caCert.Equal(ca.caCert) {
+		t.Fatal("root was regenerated")
+	}
+	second, err := reloaded.Certificate("app.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Leaf.Equal(first.Leaf) {
+		t.Fatal("leaf was reissued instead of loaded")
+	}
+
+	sites, err := reloaded.ListSites()
+	if err != nil || len(sites) != 1 || sites[0].Hostname != "app.test" {
+		t.Fatalf("sites %+v, %v", sites, err)
+	}
+
+	// Leaves signed by a previous root are reissued
+	other, _ := newTestAuthority(t)
+	writeLeaf(t, other, "old.test", time.Now().Add(leafValidity))
+	for _, name := range []string{"old.test.pem", "old.test-key.pem"} {
+		data, _ := os.ReadFile(filepath.Join(other.dir, sitesDir, name))
+		os.WriteFile(filepath.Join(dir, sitesDir, name), data, 0o600)
+	}
+	cert, err := reloaded.Certificate("old.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.issuedByRoot(cert.Leaf) {
+		t.Fatal("leaf from another root was used")
+	}
+}
+
+func TestRenewal(t *testing.T) {
+	ca, _ := newTestAuthority(t)
+
+	if !needsRenewal(nil) {
+		t.Fatal("missing leaf does not need renewal")
+	}
+	writeLeaf(t, ca, "soon.test", time.Now().Add(RenewBefore-time.Hour))
+	writeLeaf(t, ca, "later.test", time.Now().Add(RenewBefore+24*time.Hour))
+
+	sites, err := ca.ListSites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, site := range sites {
+		cert, err := tls.LoadX509KeyPair(site.CertPath, site.KeyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := needsRenewal(cert.Leaf), site.Hostname == "soon.test"; got != want {
+			t.Fatalf("needsRenewal(%s) = %v", site.Hostname, got)
+		}
+	}
+
+	rotated, err := ca.RotateExpiring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 || rotated[0] != "soon.test" {
+		t.Fatalf("rotated %v, want [soon.test]", rotated)
+	}
+	cert, err := ca.Certificate("soon.test")
+	if err != nil || needsRenewal(cert.Leaf) {
+		t.Fatalf("rotated leaf still expiring: %v", err)
+	}
+	if rotated, _ := ca.RotateExpiring(); len(rotated) != 0 {
+		t.Fatalf("second rotation rotated %v", rotated)
+	}
+}
+
+func TestNormalizeHostname(t *testing.T) {
+	for in, want := range map[string]string{
+		"App.Test":   "app.test",
+		" api.test.": "api.test",
+		"10.0.0.5":   "10.0.0.5",
+		"my-site.lo": "my-site.lo",
+	} {
+		if got, err := normalizeHostname(in); err != nil || got != want {
+			t.Errorf("normalizeHostname(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", " ", "../etc/passwd", "a/b.test", `a\b.test`, "a..b", ".hidden", "foo bar.test", "*.test", "::1", "bad_name.test"} {
+		if got, err := normalizeHostname(in); err == nil {
+			t.Errorf("normalizeHostname(%q) = %q, want an error", in, got)
+		}
+	}
+}
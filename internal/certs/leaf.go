@@ -0,0 +1,258 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	leafValidity = 90 * 24 * time.Hour
+	// RenewBefore is how long before expiry a leaf certificate is reissued
+	RenewBefore = 30 * 24 * time.Hour
+)
+
+// SiteCertificate describes the files of an issued leaf certificate, for use in web-server vhosts
+type SiteCertificate struct {
+	Hostname string    `json:"hostname"`
+	CertPath string    `json:"certPath"`
+	KeyPath  string    `json:"keyPath"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// Certificate returns a valid leaf certificate for hostname, issuing or rotating it when needed
+func (ca *Authority) Certificate(hostname string) (*tls.Certificate, error) {
+	hostname, err := normalizeHostname(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	ca.mutex.RLock()
+	cert, exists := ca.leaves[hostname]
+	ca.mutex.RUnlock()
+	if exists && !needsRenewal(cert.Leaf) {
+		return cert, nil
+	}
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	return ca.loadOrIssue(hostname)
+}
+
+// IssueSite ensures a leaf certificate exists on disk for hostname and returns its file paths
+func (ca *Authority) IssueSite(hostname string) (*SiteCertificate, error) {
+	cert, err := ca.Certificate(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ = normalizeHostname(hostname)
+	return &SiteCertificate{
+		Hostname: hostname,
+		CertPath: ca.leafCertPath(hostname),
+		KeyPath:  ca.leafKeyPath(hostname),
+		NotAfter: cert.Leaf.NotAfter,
+	}, nil
+}
+
+// ListSites returns all leaf certificates issued to disk
+func (ca *Authority) ListSites() ([]SiteCertificate, error) {
+	ca.mutex.RLock()
+	defer ca.mutex.RUnlock()
+
+	matches, err := filepath.Glob(filepath.Join(ca.dir, sitesDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var sites []SiteCertificate
+	for _, certPath := range matches {
+		if strings.HasSuffix(certPath, "-key.pem") {
+			continue
+		}
+		hostname := strings.TrimSuffix(filepath.Base(certPath), ".pem")
+		cert, err := tls.LoadX509KeyPair(certPath, ca.leafKeyPath(hostname))
+		if err != nil {
+			continue
+		}
+		sites = append(sites, SiteCertificate{
+			Hostname: hostname,
+			CertPath: certPath,
+			KeyPath:  ca.leafKeyPath(hostname),
+			NotAfter: cert.Leaf.NotAfter,
+		})
+	}
+	return sites, nil
+}
+
+// RotateExpiring reissues every leaf certificate that is within the renewal window
+// and returns the hostnames that were rotated
+func (ca *Authority) RotateExpiring() ([]string, error) {
+	sites, err := ca.ListSites()
+	if err != nil {
+		return nil, err
+	}
+
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	var rotated []string
+	for _, site := range sites {
+		if time.Until(site.NotAfter) > RenewBefore {
+			continue
+		}
+		if _, err := ca.issue(site.Hostname); err != nil {
+			return rotated, fmt.Errorf("failed to rotate %s: %w", site.Hostname, err)
+		}
+		rotated = append(rotated, site.Hostname)
+	}
+	return rotated, nil
+}
+
+// WatchRotation periodically rotates expiring leaf certificates until stop is called
+func (ca *Authority) WatchRotation(interval time.Duration) (stop func()) {
+	stopChan := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if rotated, err := ca.RotateExpiring(); err != nil {
+				log.Printf("Certificate rotation failed: %v", err)
+			} else if len(rotated) > 0 {
+				log.Printf("Rotated certificates: %s", strings.Join(rotated, ", "))
+			}
+
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() { close(stopChan) }
+}
+
+// loadOrIssue returns the on-disk leaf for hostname or issues a new one (assumes lock is held)
+func (ca *Authority) loadOrIssue(hostname string) (*tls.Certificate, error) {
+	if cert, exists := ca.leaves[hostname]; exists && !needsRenewal(cert.Leaf) {
+		return cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(ca.leafCertPath(hostname), ca.leafKeyPath(hostname))
+	if err == nil && !needsRenewal(cert.Leaf) && ca.issuedByRoot(cert.Leaf) {
+		ca.leaves[hostname] = &cert
+		return &cert, nil
+	}
+
+	return ca.issue(hostname)
+}
+
+// issue signs a new leaf certificate for hostname and writes it to disk (assumes lock is held)
+func (ca *Authority) issue(hostname string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := time.Now().Add(leafValidity)
+	if notAfter.After(ca.caCert.NotAfter) {
+		notAfter = ca.caCert.NotAfter
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"Enty Development Certificate"},
+			CommonName:   hostname,
+		},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	if ip := net.ParseIP(hostname); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{hostname}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.caCert, &key.PublicKey, ca.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate for %s: %w", hostname, err)
+	}
+
+	if err := writeKey(ca.leafKeyPath(hostname), key); err != nil {
+		return nil, err
+	}
+	if err := writeCert(ca.leafCertPath(hostname), der); err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	ca.leaves[hostname] = cert
+	return cert, nil
+}
+
+// issuedByRoot reports whether the leaf was signed by the current root (it may have been regenerated)
+func (ca *Authority) issuedByRoot(leaf *x509.Certificate) bool {
+	return leaf != nil && leaf.CheckSignatureFrom(ca.caCert) == nil
+}
+
+func (ca *Authority) leafCertPath(hostname string) string {
+	return filepath.Join(ca.dir, sitesDir, hostname+".pem")
+}
+
+func (ca *Authority) leafKeyPath(hostname string) string {
+	return filepath.Join(ca.dir, sitesDir, hostname+"-key.pem")
+}
+
+func needsRenewal(leaf *x509.Certificate) bool {
+	return leaf == nil || time.Until(leaf.NotAfter) <= RenewBefore
+}
+
+// normalizeHostname lowercases hostname and rejects values that are unsafe to use as file names
+func normalizeHostname(hostname string) (string, error) {
+	hostname = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(hostname)), ".")
+	if hostname == "" {
+		return "", fmt.Errorf("hostname is required")
+	}
+	if net.ParseIP(hostname) != nil && !strings.Contains(hostname, ":") {
+		return hostname, nil
+	}
+
+	for _, r := range hostname {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+		default:
+			return "", fmt.Errorf("invalid hostname: %s", hostname)
+		}
+	}
+	if strings.Contains(hostname, "..") || strings.HasPrefix(hostname, ".") {
+		return "", fmt.Errorf("invalid hostname: %s", hostname)
+	}
+	return hostname, nil
+}
@@ -0,0 +1,325 @@
+// Package cli implements the headless enty command, which exposes the same packages as the
+// Wails bindings for scripting in CI containers and provisioning scripts.
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/control"
+	"github.com/JadlionHD/Enty/internal/install"
+	"github.com/JadlionHD/Enty/internal/runner"
+	"github.com/JadlionHD/Enty/internal/service"
+	"github.com/JadlionHD/Enty/internal/shellenv"
+	"github.com/JadlionHD/Enty/internal/shim"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+const usage = `usage: enty <command> [arguments]
+
+commands:
+  versions list <service>           list installed and downloadable versions
+  install <service> <version>       download and register a service version
+  service start <service> [version] start a background service
+  service stop <service>            stop a background service
+  service status [service]          show background service state
+  sessions list                     list terminal sessions of the running app
+  env [--project DIR] [--shell SH]  print the isolated environment for a shell
+  run [--service S] [--dir DIR] [--timeout SEC] -- <command> [args]
+                                    run a command in the isolated environment
+  call <method> [params-json]       call a control API method of the running app
+`
+
+// Run executes the command line args (without the program name) and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	config.UseExecutableConfigDir()
+
+	var err error
+	switch args[0] {
+	case shim.Command:
+		return shim.Run(args[1:])
+	case shellenv.Command:
+		return shellenv.Run(args[1:], stdout, stderr)
+	case "run":
+		return runCommand(args[1:], stdout, stderr)
+	case "versions":
+		if len(args) != 3 || args[1] != "list" {
+			return usageError(stderr, "versions list <service>")
+		}
+		err = listVersions(stdout, args[2])
+	case "install":
+		if len(args) != 3 {
+			return usageError(stderr, "install <service> <version>")
+		}
+		err = installVersion(stdout, stderr, args[1], args[2])
+	case "service":
+		err = runService(stdout, stderr, args[1:])
+	case "sessions":
+		if len(args) != 2 || args[1] != "list" {
+			return usageError(stderr, "sessions list")
+		}
+		err = listSessions(stdout)
+	case "call":
+		if len(args) < 2 || len(args) > 3 {
+			return usageError(stderr, "call <method> [params-json]")
+		}
+		params := ""
+		if len(args) == 3 {
+			params = args[2]
+		}
+		err = callMethod(stdout, args[1], params)
+	default:
+		fmt.Fprintf(stderr, "enty: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if err != nil {
+		if _, ok := err.(usageErr); ok {
+			return usageError(stderr, err.Error())
+		}
+		fmt.Fprintf(stderr, "enty: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// usageErr marks errors caused by invalid arguments
+type usageErr string
+
+func (e usageErr) Error() string { return string(e) }
+
+func usageError(stderr io.Writer, syntax string) int {
+	fmt.Fprintf(stderr, "usage: enty %s\n", syntax)
+	return 2
+}
+
+func listVersions(stdout io.Writer, serviceName string) error {
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
+	if err := pathsConfig.LoadConfig(); err != nil {
+		return err
+	}
+
+	activePath, _ := pathsConfig.GetServicePath(serviceName)
+	installed := pathsConfig.GetServiceVersions(serviceName)
+
+	versions := make([]string, 0, len(installed))
+	for version := range installed {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return config.CompareVersions(versions[i], versions[j]) > 0
+	})
+
+	available, err := install.Available(serviceName)
+	if err != nil && len(versions) == 0 {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tSTATUS\tPATH")
+	for _, version := range versions {
+		status := "installed"
+		if installed[version] == activePath {
+			status = "active"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", version, status, installed[version])
+	}
+	for _, version := range available {
+		if _, ok := installed[version]; !ok {
+			fmt.Fprintf(w, "%s\t%s\t\n", version, "available")
+		}
+	}
+	return w.Flush()
+}
+
+func installVersion(stdout, stderr io.Writer, serviceName, version string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	lastPercent := -1
+	result, err := install.Install(ctx, serviceName, version, func(downloaded, total int64) {
+		if total <= 0 {
+			return
+		}
+		if percent := int(downloaded * 100 / total); percent/10 != lastPercent/10 {
+			lastPercent = percent
+			fmt.Fprintf(stderr, "downloading %s %s: %d%%\n", serviceName, version, percent)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "installed %s %s in %s\n", result.Service, result.Version, result.Path)
+	return nil
+}
+
+func runService(stdout, stderr io.Writer, args []string) error {
+	if len(args) == 0 {
+		return usageErr("service start|stop|status <service>")
+	}
+
+	switch args[0] {
+	case "start":
+		if len(args) < 2 || len(args) > 3 {
+			return usageErr("service start <service> [version]")
+		}
+		version := ""
+		if len(args) == 3 {
+			version = args[2]
+		}
+		status, err := service.Start(args[1], version)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "started %s (pid %d), logging to %s\n", status.Service, status.PID, status.LogFile)
+	case "stop":
+		if len(args) != 2 {
+			return usageErr("service stop <service>")
+		}
+		if err := service.Stop(args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "stopped %s\n", strings.ToLower(args[1]))
+	case "status", "list":
+		statuses := service.List()
+		if len(args) == 2 {
+			statuses = []*service.Status{service.Get(args[1])}
+		}
+		w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tSTATUS\tPID")
+		for _, status := range statuses {
+			state, pid := "stopped", ""
+			if status.Running {
+				state, pid = "running", fmt.Sprint(status.PID)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", status.Service, state, pid)
+		}
+		return w.Flush()
+	default:
+		return usageErr("service start|stop|status <service>")
+	}
+	return nil
+}
+
+// dialControl connects to the control API of the running app
+func dialControl() (*control.Client, error) {
+	endpointFile, err := config.DataPath("run", "control.json")
+	if err != nil {
+		return nil, err
+	}
+	return control.Dial(endpointFile)
+}
+
+func callMethod(stdout io.Writer, method, params string) error {
+	client, err := dialControl()
+	if err != nil {
+		return err
+	}
+
+	var rawParams json.RawMessage
+	if params != "" {
+		if !json.Valid([]byte(params)) {
+			return usageErr("call <method> [params-json]: params must be valid JSON")
+		}
+		rawParams = json.RawMessage(params)
+	}
+
+	var result json.RawMessage
+	if err := client.Call(method, rawParams, &result); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if len(result) == 0 || json.Indent(&out, result, "", "  ") != nil {
+		out.Reset()
+		out.Write(result)
+	}
+	fmt.Fprintln(stdout, out.String())
+	return nil
+}
+
+func listSessions(stdout io.Writer) error {
+	// Prefer asking the running app, falling back to the state file it mirrors sessions to
+	var sessions []utils.SessionInfo
+	client, err := dialControl()
+	if err != nil || client.Call("session.list", nil, &sessions) != nil {
+		statePath, err := config.DataPath("run", "sessions.json")
+		if err != nil {
+			return err
+		}
+		var pid int
+		pid, sessions, err = utils.LoadSessionState(statePath)
+		if err != nil {
+			return err
+		}
+		if !service.ProcessAlive(pid) {
+			sessions = nil
+		}
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tTYPE\tLAST ACTIVITY")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", session.SessionID, session.TerminalType, session.LastActivity.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+// runCommand runs a command with the isolated PATH and returns its exit code. Timeouts return
+// 124 like timeout(1), and commands that could not be started return 127.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	serviceName := flags.String("service", "", "limit PATH to this service")
+	dir := flags.String("dir", "", "working directory")
+	timeout := flags.Int("timeout", 0, "stop the command after this many seconds")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return usageError(stderr, "run [--service S] [--dir DIR] [--timeout SEC] -- <command> [args]")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := runner.Run(ctx, runner.Options{
+		Args:     flags.Args(),
+		Dir:      *dir,
+		Service:  *serviceName,
+		Timeout:  time.Duration(*timeout) * time.Second,
+		OnStdout: func(data string) { io.WriteString(stdout, data) },
+		OnStderr: func(data string) { io.WriteString(stderr, data) },
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "enty: %v\n", err)
+		return 127
+	}
+
+	switch {
+	case result.TimedOut:
+		fmt.Fprintf(stderr, "enty: %s timed out after %ds\n", flags.Arg(0), *timeout)
+		return 124
+	case result.ExitCode < 0:
+		return 1
+	default:
+		return result.ExitCode
+	}
+}
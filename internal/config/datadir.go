@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// DataDirEnv overrides the default data directory location when set
+	DataDirEnv = "ENTY_HOME"
+	// ConfigDirEnv overrides the bundled config directory, which is otherwise relative to the working directory
+	ConfigDirEnv = "ENTY_CONFIG_DIR"
+)
+
+// ConfigPath returns the path of a bundled configuration file such as paths.json
+func ConfigPath(name string) string {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	return filepath.Join("config", name)
+}
+
+// UseExecutableConfigDir points ConfigPath at the config directory next to the executable
+// when ENTY_CONFIG_DIR is unset. Headless commands run from arbitrary working directories,
+// where the relative default would not resolve.
+func UseExecutableConfigDir() {
+	if os.Getenv(ConfigDirEnv) != "" {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	dir := filepath.Join(filepath.Dir(exe), "config")
+	if stat, err := os.Stat(dir); err == nil && stat.IsDir() {
+		os.Setenv(ConfigDirEnv, dir)
+	}
+}
+
+// DataDir returns the directory used for Enty's persistent state (certificates, stores, registries).
+// It defaults to ~/.enty and can be overridden with the ENTY_HOME environment variable.
+func DataDir() (string, error) {
+	if dir := os.Getenv(DataDirEnv); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".enty"), nil
+}
+
+// DataPath returns a path inside the data directory, creating the parent directories if needed
+func DataPath(elem ...string) (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(append([]string{dir}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return path, nil
+}
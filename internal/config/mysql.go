@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"runtime"
+	"strings"
 )
 
 type ConfigVersionMySQL struct {
@@ -30,9 +32,14 @@ type ConfigDataMySQL struct {
 }
 
 func (c *configs) GetMySqlConfig() (*ConfigVersionMySQL, error) {
+	return LoadMySqlConfig()
+}
+
+// LoadMySqlConfig reads the MySQL download catalog from config/mysql.json
+func LoadMySqlConfig() (*ConfigVersionMySQL, error) {
 	var config ConfigVersionMySQL
 
-	file, err := os.Open("config/mysql.json")
+	file, err := os.Open(ConfigPath("mysql.json"))
 	if err != nil {
 		slog.Error("failed to read config", "error", err)
 		return nil, err
@@ -43,3 +50,42 @@ func (c *configs) GetMySqlConfig() (*ConfigVersionMySQL, error) {
 	jsonParser.Decode(&config)
 	return &config, nil
 }
+
+// CurrentConfigOSMySQL returns the catalog OS name for the running platform
+func CurrentConfigOSMySQL() ConfigOSMySQL {
+	switch runtime.GOOS {
+	case "windows":
+		return ConfigOSMySQLWindows
+	case "darwin":
+		return ConfigOSMySQLMacOS
+	default:
+		return ConfigOSMySQLLinux
+	}
+}
+
+// FindServiceDownload returns the newest downloadable release of a service matching requested
+// for the running platform. Only services with a download catalog are supported.
+func FindServiceDownload(serviceName, requested string) (*ConfigDataMySQL, bool) {
+	if strings.ToLower(serviceName) != "mysql" {
+		return nil, false
+	}
+
+	catalog, err := LoadMySqlConfig()
+	if err != nil {
+		return nil, false
+	}
+
+	var best *ConfigDataMySQL
+	for _, arch := range catalog.Mysql {
+		if arch.Os != CurrentConfigOSMySQL() {
+			continue
+		}
+		for i := range arch.Data {
+			data := &arch.Data[i]
+			if VersionMatches(data.Version, requested) && (best == nil || CompareVersions(data.Version, best.Version) > 0) {
+				best = data
+			}
+		}
+	}
+	return best, best != nil
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -30,12 +29,12 @@ func LivePathsConfigManager() *PathsConfigManager {
 	livePathsConfigLock.Lock()
 	defer livePathsConfigLock.Unlock()
 	if livePathsConfig == nil {
-		livePathsConfig = NewPathsConfigManager(filepath.Join("config", "paths.json"))
+		livePathsConfig = NewPathsConfigManager(ConfigPath("paths.json"))
 		_ = livePathsConfig.LoadConfig()
 		if watcherStopFunc != nil {
 			watcherStopFunc()
 		}
-		watcherStopFunc = configwatch.WatchConfigFile(filepath.Join("config", "paths.json"), 2*time.Second, func() {
+		watcherStopFunc = configwatch.WatchConfigFile(ConfigPath("paths.json"), 2*time.Second, func() {
 			livePathsConfigLock.Lock()
 			_ = livePathsConfig.LoadConfig()
 			livePathsConfigLock.Unlock()
@@ -49,6 +48,8 @@ type ServicePathsConfig struct {
 	ServicePaths      map[string]string `json:"servicePaths"`
 	DefaultPaths      []string          `json:"defaultPaths"`
 	StandardUnixPaths []string          `json:"standardUnixPaths,omitempty"`
+	// ServiceVersions lists the installed versions of each service and their paths
+	ServiceVersions map[string]map[string]string `json:"serviceVersions,omitempty"`
 }
 
 // PathsConfigManager manages the service paths configuration
@@ -68,7 +69,7 @@ func NewPathsConfigManager(configPath string) *PathsConfigManager {
 func (pcm *PathsConfigManager) LoadConfig() error {
 	if pcm.configPath == "" {
 		// Use default config path
-		pcm.configPath = filepath.Join("config", "paths.json")
+		pcm.configPath = ConfigPath("paths.json")
 	}
 
 	// Check if file exists
@@ -186,3 +187,118 @@ func (pcm *PathsConfigManager) GetAllServicePaths() map[string]string {
 	}
 	return pcm.config.ServicePaths
 }
+
+// GetServiceVersions returns the installed versions of a service mapped to their paths
+func (pcm *PathsConfigManager) GetServiceVersions(serviceName string) map[string]string {
+	if pcm.config == nil {
+		return map[string]string{}
+	}
+
+	versions, exists := pcm.config.ServiceVersions[strings.ToLower(serviceName)]
+	if !exists {
+		return map[string]string{}
+	}
+	return versions
+}
+
+// GetInstalledServices returns the names of all services with installed versions
+func (pcm *PathsConfigManager) GetInstalledServices() []string {
+	if pcm.config == nil {
+		return []string{}
+	}
+
+	services := make([]string, 0, len(pcm.config.ServiceVersions))
+	for serviceName := range pcm.config.ServiceVersions {
+		services = append(services, serviceName)
+	}
+	return services
+}
+
+// ResolveServiceVersion finds the newest installed version of a service matching the requested version.
+// A partial version such as "8.2" matches any installed "8.2.x" release.
+func (pcm *PathsConfigManager) ResolveServiceVersion(serviceName, requested string) (version, path string, ok bool) {
+	requested = strings.TrimPrefix(strings.TrimSpace(requested), "v")
+
+	for installed, installedPath := range pcm.GetServiceVersions(serviceName) {
+		if !VersionMatches(installed, requested) {
+			continue
+		}
+		if !ok || CompareVersions(installed, version) > 0 {
+			version, path, ok = installed, installedPath, true
+		}
+	}
+	return version, path, ok
+}
+
+// VersionMatches reports whether version satisfies requested, where requested may be a prefix ("8", "8.2")
+func VersionMatches(version, requested string) bool {
+	version = strings.TrimPrefix(version, "v")
+	requested = strings.TrimPrefix(requested, "v")
+	if requested == "" || requested == "*" {
+		return true
+	}
+	return version == requested || strings.HasPrefix(version, requested+".")
+}
+
+// CompareVersions compares dotted version strings numerically, returning -1, 0 or 1
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aNum, bNum int
+		if i < len(aParts) {
+			fmt.Sscanf(aParts[i], "%d", &aNum)
+		}
+		if i < len(bParts) {
+			fmt.Sscanf(bParts[i], "%d", &bNum)
+		}
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// AddServiceVersion records an installed version of a service and saves the configuration.
+// The version also becomes the active service path when activate is set or none is configured yet.
+func (pcm *PathsConfigManager) AddServiceVersion(serviceName, version, path string, activate bool) error {
+	if pcm.config == nil {
+		if err := pcm.LoadConfig(); err != nil {
+			return err
+		}
+	}
+
+	serviceName = strings.ToLower(serviceName)
+	if pcm.config.ServiceVersions == nil {
+		pcm.config.ServiceVersions = make(map[string]map[string]string)
+	}
+	if pcm.config.ServiceVersions[serviceName] == nil {
+		pcm.config.ServiceVersions[serviceName] = make(map[string]string)
+	}
+	pcm.config.ServiceVersions[serviceName][version] = path
+
+	if pcm.config.ServicePaths == nil {
+		pcm.config.ServicePaths = make(map[string]string)
+	}
+	if _, exists := pcm.config.ServicePaths[serviceName]; activate || !exists {
+		pcm.config.ServicePaths[serviceName] = path
+	}
+
+	return pcm.save()
+}
+
+// save writes the configuration back to the JSON file
+func (pcm *PathsConfigManager) save() error {
+	data, err := json.MarshalIndent(pcm.config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode paths config: %w", err)
+	}
+	if err := os.WriteFile(pcm.configPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write paths config file: %w", err)
+	}
+	return nil
+}
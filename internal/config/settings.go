@@ -0,0 +1,244 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/configwatch"
+)
+
+var (
+	liveSettings        *SettingsManager
+	liveSettingsLock    sync.Mutex
+	settingsWatcherStop func()
+)
+
+// LiveSettingsManager returns a singleton settings manager that auto-reloads on file changes.
+func LiveSettingsManager() *SettingsManager {
+	liveSettingsLock.Lock()
+	defer liveSettingsLock.Unlock()
+
+	if liveSettings == nil {
+		settingsPath := ConfigPath("settings.json")
+		liveSettings = NewSettingsManager(settingsPath)
+		_ = liveSettings.LoadConfig()
+		if settingsWatcherStop != nil {
+			settingsWatcherStop()
+		}
+		settingsWatcherStop = configwatch.WatchConfigFile(settingsPath, 2*time.Second, func() {
+			_ = liveSettings.LoadConfig()
+		})
+	}
+	return liveSettings
+}
+
+// Settings holds user-configurable application settings
+type Settings struct {
+	DNS      DNSSettings      `json:"dns"`
+	Mail     MailSettings     `json:"mail"`
+	MySQL    MySQLSettings    `json:"mysql"`
+	Control  ControlSettings  `json:"control"`
+	Terminal TerminalSettings `json:"terminal"`
+}
+
+// DNSSettings configures the embedded development DNS resolver
+type DNSSettings struct {
+	Enabled bool `json:"enabled"`
+	// Address must be a loopback address, as the resolver forwards other names upstream
+	Address string   `json:"address"`
+	Port    int      `json:"port"`
+	Domains []string `json:"domains"`
+}
+
+// MailSettings configures the embedded SMTP mail catcher
+type MailSettings struct {
+	Enabled bool `json:"enabled"`
+	// Address must be a loopback address, as the catcher accepts mail from anyone who connects
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+}
+
+// MySQLSettings holds the administrative connection used to provision project databases
+type MySQLSettings struct {
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	RootUser     string `json:"rootUser"`
+	RootPassword string `json:"rootPassword"`
+}
+
+// ControlSettings configures the local JSON-RPC control API used by scripts and editors
+type ControlSettings struct {
+	Enabled bool `json:"enabled"`
+	// Port is the loopback TCP port used where Unix sockets are not available; 0 picks a free port
+	Port int `json:"port"`
+}
+
+// TerminalSettings configures terminal sessions and the transports they are exposed on
+type TerminalSettings struct {
+	// ScrollbackSize is the number of output bytes each session keeps for replay after a UI reload
+	ScrollbackSize int                    `json:"scrollbackSize"`
+	Bridge         TerminalBridgeSettings `json:"bridge"`
+	Timeout        TerminalTimeout        `json:"timeout"`
+	// Profiles are user-defined terminal types shown next to the discovered shells
+	Profiles []TerminalProfile `json:"profiles"`
+	Persist  TerminalPersist   `json:"persist"`
+}
+
+// TerminalPersist controls saving open sessions on exit so they can be recreated on the next launch
+type TerminalPersist struct {
+	Enabled bool `json:"enabled"`
+	// ScrollbackBytes is the amount of recent output saved per session; 0 saves none
+	ScrollbackBytes int `json:"scrollbackBytes"`
+}
+
+// Actions taken when a terminal session reaches its inactivity timeout
+const (
+	TimeoutActionKill   = "kill"
+	TimeoutActionDetach = "detach"
+)
+
+// TerminalTimeout is the inactivity policy of terminal sessions
+type TerminalTimeout struct {
+	// Minutes of inactivity before the session expires; 0 disables the timeout
+	Minutes int `json:"minutes"`
+	// WarningSeconds is how long before expiry a warning is emitted; 0 disables the warning
+	WarningSeconds int `json:"warningSeconds"`
+	// InputOnly counts only keyboard input as activity, so continuous output does not keep a session alive
+	InputOnly bool `json:"inputOnly"`
+	// Action is TimeoutActionKill or TimeoutActionDetach, which leaves the process running for a later attach
+	Action string `json:"action"`
+}
+
+// TerminalProfile is a named shell configuration
+type TerminalProfile struct {
+	Name       string   `json:"name"`
+	Executable string   `json:"executable"`
+	Args       []string `json:"args,omitempty"`
+	Cwd        string   `json:"cwd,omitempty"`
+	// Env overrides variables of the inherited environment
+	Env map[string]string `json:"env,omitempty"`
+	// Services limits the services placed on PATH; empty means all configured services
+	Services []string `json:"services,omitempty"`
+}
+
+// TerminalBridgeSettings configures the WebSocket bridge used by browsers and remote editors
+type TerminalBridgeSettings struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+}
+
+// DefaultSettings returns the settings used when no settings file exists
+func DefaultSettings() Settings {
+	return Settings{
+		DNS: DNSSettings{
+			Enabled: false,
+			Address: "127.0.0.1",
+			Port:    5300,
+			Domains: []string{"test"},
+		},
+		Mail: MailSettings{
+			Enabled: false,
+			Address: "127.0.0.1",
+			Port:    1025,
+		},
+		MySQL: MySQLSettings{
+			Host:     "127.0.0.1",
+			Port:     3306,
+			RootUser: "root",
+		},
+		Control: ControlSettings{
+			Enabled: false,
+			Port:    0,
+		},
+		Terminal: TerminalSettings{
+			ScrollbackSize: 256 * 1024,
+			Bridge: TerminalBridgeSettings{
+				Enabled: false,
+				Address: "127.0.0.1",
+				Port:    7681,
+			},
+			Timeout: TerminalTimeout{
+				Minutes:        60,
+				WarningSeconds: 60,
+				InputOnly:      false,
+				Action:         TimeoutActionKill,
+			},
+			Profiles: []TerminalProfile{},
+			Persist: TerminalPersist{
+				Enabled:         true,
+				ScrollbackBytes: 64 * 1024,
+			},
+		},
+	}
+}
+
+// SettingsManager loads and saves the application settings file
+type SettingsManager struct {
+	settings     Settings
+	settingsPath string
+	mutex        sync.RWMutex
+}
+
+// NewSettingsManager creates a new settings manager
+func NewSettingsManager(settingsPath string) *SettingsManager {
+	return &SettingsManager{
+		settings:     DefaultSettings(),
+		settingsPath: settingsPath,
+	}
+}
+
+// LoadConfig loads the settings from the JSON file, keeping defaults for missing fields
+func (sm *SettingsManager) LoadConfig() error {
+	data, err := os.ReadFile(sm.settingsPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	settings := DefaultSettings()
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse settings JSON: %w", err)
+	}
+
+	sm.mutex.Lock()
+	sm.settings = settings
+	sm.mutex.Unlock()
+	return nil
+}
+
+// Get returns the current settings
+func (sm *SettingsManager) Get() Settings {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.settings
+}
+
+// Update applies fn to the current settings and saves the result
+func (sm *SettingsManager) Update(fn func(*Settings)) error {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	settings := sm.settings
+	fn(&settings)
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(sm.settingsPath), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	if err := os.WriteFile(sm.settingsPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write settings file: %w", err)
+	}
+
+	sm.settings = settings
+	return nil
+}
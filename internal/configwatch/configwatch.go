@@ -11,7 +11,17 @@ import (
 // WatchConfigFile watches a config file for changes using polling (mod time).
 // onChange is called whenever the file is modified.
 func WatchConfigFile(configPath string, interval time.Duration, onChange func()) (stop func()) {
-	absPath, err := filepath.Abs(configPath)
+	return watch(configPath, interval, false, onChange)
+}
+
+// WatchFile is like WatchConfigFile for optional files: onChange is also called once when
+// watching starts, whether or not the file exists, and whenever the file is removed.
+func WatchFile(path string, interval time.Duration, onChange func()) (stop func()) {
+	return watch(path, interval, true, onChange)
+}
+
+func watch(path string, interval time.Duration, optional bool, onChange func()) (stop func()) {
+	absPath, err := filepath.Abs(path)
 	if err != nil {
 		log.Printf("WatchConfigFile: %v", err)
 		return func() {}
@@ -19,19 +29,29 @@ func WatchConfigFile(configPath string, interval time.Duration, onChange func())
 	stopChan := make(chan struct{})
 	go func() {
 		var lastModTime time.Time
+		// exists tracks the last stat, so a removal is reported once
+		exists := false
+		first := true
 		for {
 			select {
 			case <-stopChan:
 				return
 			default:
 				info, err := os.Stat(absPath)
-				if err == nil {
+				switch {
+				case err == nil:
 					modTime := info.ModTime()
-					if modTime.After(lastModTime) {
+					if modTime.After(lastModTime) || (optional && !exists) {
 						onChange()
 						lastModTime = modTime
 					}
+					exists = true
+				case os.IsNotExist(err) && optional && (exists || first):
+					onChange()
+					exists = false
+					lastModTime = time.Time{}
 				}
+				first = false
 				time.Sleep(interval)
 			}
 		}
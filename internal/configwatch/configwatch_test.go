@@ -0,0 +1,63 @@
+package configwatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFileReportsRemoval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".enty.json")
+	changes := make(chan struct{}, 10)
+	stop := WatchFile(path, 5*time.Millisecond, func() { changes <- struct{}{} })
+	defer stop()
+
+	expect := func(what string) {
+		t.Helper()
+		select {
+		case <-changes:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no change reported for %s", what)
+		}
+	}
+	quiet := func(what string) {
+		t.Helper()
+		select {
+		case <-changes:
+			t.Fatalf("unexpected change after %s", what)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	// The initial state is reported even though the file does not exist
+	expect("start")
+	quiet("start")
+
+	os.WriteFile(path, []byte("{}"), 0o644)
+	expect("creation")
+	quiet("creation")
+
+	os.Remove(path)
+	expect("removal")
+	quiet("removal")
+
+	os.WriteFile(path, []byte("{}"), 0o644)
+	expect("recreation")
+}
+
+func TestWatchConfigFileIgnoresMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(path, []byte("{}"), 0o644)
+	changes := make(chan struct{}, 10)
+	stop := WatchConfigFile(path, 5*time.Millisecond, func() { changes <- struct{}{} })
+	defer stop()
+
+	<-changes
+	os.Remove(path)
+	select {
+	case <-changes:
+		t.Fatal("removal of a config file was reported")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,101 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotRunning is returned by Dial when no control server endpoint is published
+var ErrNotRunning = errors.New("enty is not running with the control server enabled")
+
+// Client calls methods on a running control server
+type Client struct {
+	endpoint   Endpoint
+	httpClient *http.Client
+	nextID     atomic.Int64
+}
+
+// Dial reads the endpoint file and returns a client for the server it describes
+func Dial(endpointFile string) (*Client, error) {
+	data, err := os.ReadFile(endpointFile)
+	if os.IsNotExist(err) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoint Endpoint
+	if err := json.Unmarshal(data, &endpoint); err != nil {
+		return nil, fmt.Errorf("invalid control endpoint file: %w", err)
+	}
+	return NewClient(endpoint), nil
+}
+
+// NewClient creates a client for endpoint
+func NewClient(endpoint Endpoint) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, endpoint.Network, endpoint.Address)
+		},
+	}
+	return &Client{
+		endpoint:   endpoint,
+		httpClient: &http.Client{Transport: transport, Timeout: 10 * time.Minute},
+	}
+}
+
+// Call invokes method with params and decodes the result into result, which may be nil
+func (c *Client) Call(method string, params, result interface{}) error {
+	req := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int64       `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params,omitempty"`
+	}{JSONRPC: "2.0", ID: c.nextID.Add(1), Method: method, Params: params}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, "http://enty/rpc", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.endpoint.Token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.endpoint.Token)
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to reach enty: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("control server returned %s", httpResp.Status)
+	}
+
+	var resp response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return fmt.Errorf("invalid control response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package control
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns a client for server using the TCP endpoint and token
+func newTestClient(server *httptest.Server, token string) *Client {
+	return NewClient(Endpoint{
+		Network: "tcp",
+		Address: strings.TrimPrefix(server.URL, "http://"),
+		Token:   token,
+	})
+}
+
+func TestClientCall(t *testing.T) {
+	_, server := newTestServer(t)
+	client := newTestClient(server, testToken)
+
+	var result string
+	if err := client.Call("echo", map[string]string{"text": "hi"}, &result); err != nil || result != "hi" {
+		t.Fatalf("echo = %q, %v", result, err)
+	}
+	// A nil result discards the value
+	if err := client.Call("echo", map[string]string{"text": "hi"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.Call("echo", nil, &result)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeInvalidParams {
+		t.Fatalf("missing params: %v, want invalid params", err)
+	}
+	if err := client.Call("missing", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatalf("unknown method: %v, want method not found", err)
+	}
+}
+
+func TestClientWrongToken(t *testing.T) {
+	_, server := newTestServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		err := newTestClient(server, token).Call("echo", map[string]string{"text": "hi"}, nil)
+		if err == nil || !strings.Contains(err.Error(), "401") {
+			t.Fatalf("token %q: %v, want 401", token, err)
+		}
+	}
+}
+
+func TestDial(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := Dial(filepath.Join(dir, "missing.json")); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("missing endpoint file: %v, want ErrNotRunning", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte("{"), 0o600)
+	if _, err := Dial(invalid); err == nil {
+		t.Fatal("invalid endpoint file accepted")
+	}
+}
@@ -0,0 +1,306 @@
+// Package control implements the local JSON-RPC 2.0 control API of a running Enty instance.
+// On Linux it listens on a Unix socket protected by file permissions; elsewhere it listens on
+// loopback TCP and requires the bearer token written to the endpoint file.
+package control
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	gosruntime "runtime"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Endpoint describes how clients reach the control server. It is written to the endpoint file.
+type Endpoint struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+	Token   string `json:"token,omitempty"`
+	PID     int    `json:"pid"`
+}
+
+// Method handles a call; params holds the raw JSON params, which may be empty
+type Method func(params json.RawMessage) (interface{}, error)
+
+// Error is a JSON-RPC error object. Methods may return it to control the code sent to the client.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// InvalidParams returns an error reporting bad method parameters
+func InvalidParams(format string, args ...interface{}) error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// ServerOptions configures where the control server listens
+type ServerOptions struct {
+	// EndpointFile receives the Endpoint so clients can find the server
+	EndpointFile string
+	// SocketPath is the Unix socket used on Linux
+	SocketPath string
+	// TCPAddr is the loopback address used on other platforms, e.g. "127.0.0.1:0"
+	TCPAddr string
+}
+
+// Server serves registered methods over HTTP POST
+type Server struct {
+	opts       ServerOptions
+	methods    map[string]Method
+	mutex      sync.RWMutex
+	httpServer *http.Server
+	listener   net.Listener
+	endpoint   Endpoint
+}
+
+// NewServer creates a control server without any methods
+func NewServer(opts ServerOptions) *Server {
+	return &Server{
+		opts:    opts,
+		methods: make(map[string]Method),
+	}
+}
+
+// Register adds a method, replacing any previous method with the same name
+func (s *Server) Register(name string, method Method) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.methods[name] = method
+}
+
+// Start begins listening and writes the endpoint file
+func (s *Server) Start() error {
+	var endpoint Endpoint
+	var listener net.Listener
+	var err error
+
+	if gosruntime.GOOS == "linux" {
+		listener, err = listenUnix(s.opts.SocketPath)
+		if err != nil {
+			return err
+		}
+		endpoint = Endpoint{Network: "unix", Address: s.opts.SocketPath}
+	} else {
+		listener, err = net.Listen("tcp", s.opts.TCPAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", s.opts.TCPAddr, err)
+		}
+		token, err := generateToken()
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		endpoint = Endpoint{Network: "tcp", Address: listener.Addr().String(), Token: token}
+	}
+	endpoint.PID = os.Getpid()
+
+	data, err := json.MarshalIndent(endpoint, "", "  ")
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	if err := os.WriteFile(s.opts.EndpointFile, data, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write control endpoint: %w", err)
+	}
+
+	s.listener = listener
+	s.endpoint = endpoint
+	s.httpServer = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Control server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// listenUnix listens on socketPath with owner-only permissions. A socket left behind by an
+// instance that crashed is replaced, but one that still accepts connections is left alone.
+func listenUnix(socketPath string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("another Enty instance is already listening on %s", socketPath)
+	}
+	os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to secure control socket: %w", err)
+	}
+	return listener, nil
+}
+
+// Stop shuts the server down and removes the endpoint file and socket
+func (s *Server) Stop() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+
+	os.Remove(s.opts.EndpointFile)
+	if s.endpoint.Network == "unix" {
+		os.Remove(s.endpoint.Address)
+	}
+	s.httpServer = nil
+	return err
+}
+
+// Endpoint returns where the server is listening
+func (s *Server) Endpoint() Endpoint {
+	return s.endpoint
+}
+
+// ServeHTTP handles a single JSON-RPC request or a batch
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&raw); err != nil {
+		writeJSON(w, response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &Error{Code: CodeParseError, Message: "parse error"}})
+		return
+	}
+
+	if len(raw) > 0 && raw[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err != nil || len(batch) == 0 {
+			writeJSON(w, response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+				Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}})
+			return
+		}
+		responses := make([]response, 0, len(batch))
+		for _, item := range batch {
+			if resp, ok := s.handle(item); ok {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, responses)
+		return
+	}
+
+	resp, ok := s.handle(raw)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+// handle runs one request. Notifications (requests without an id) produce no response.
+func (s *Server) handle(raw json.RawMessage) (response, bool) {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return response{JSONRPC: "2.0", ID: json.RawMessage("null"),
+			Error: &Error{Code: CodeInvalidRequest, Message: "invalid request"}}, true
+	}
+
+	s.mutex.RLock()
+	method, exists := s.methods[req.Method]
+	s.mutex.RUnlock()
+
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	if !exists {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: "method not found: " + req.Method}
+	} else if result, err := method(req.Params); err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+	} else if encoded, err := json.Marshal(result); err != nil {
+		resp.Error = &Error{Code: CodeInternalError, Message: "failed to encode result: " + err.Error()}
+	} else {
+		resp.Result = encoded
+	}
+
+	if len(req.ID) == 0 {
+		return response{}, false
+	}
+	return resp, true
+}
+
+// authorized checks the bearer token on TCP; the Unix socket relies on file permissions
+func (s *Server) authorized(r *http.Request) bool {
+	if s.endpoint.Token == "" {
+		return true
+	}
+	expected := "Bearer " + s.endpoint.Token
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate control token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// DecodeParams unmarshals params into v, reporting failures as invalid params
+func DecodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return InvalidParams("invalid params: %v", err)
+	}
+	return nil
+}
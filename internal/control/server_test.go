@@ -0,0 +1,190 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"strings"
+	"testing"
+)
+
+const testToken = "secret"
+
+// newTestServer serves a server with an "echo" and a "fail" method over httptest, requiring testToken
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s := NewServer(ServerOptions{})
+	s.endpoint.Token = testToken
+	s.Register("echo", func(params json.RawMessage) (interface{}, error) {
+		var args struct {
+			Text string `json:"text"`
+		}
+		if err := DecodeParams(params, &args); err != nil {
+			return nil, err
+		}
+		if args.Text == "" {
+			return nil, InvalidParams("text is required")
+		}
+		return args.Text, nil
+	})
+	s.Register("fail", func(json.RawMessage) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+// post sends body with the given Authorization header and returns the status and decoded body
+func post(t *testing.T, url, authorization, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestRejectsMissingOrWrongToken(t *testing.T) {
+	_, server := newTestServer(t)
+	call := `{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`
+
+	for _, authorization := range []string{"", "Bearer wrong", testToken, "Bearer " + testToken + "x"} {
+		if status, _ := post(t, server.URL, authorization, call); status != http.StatusUnauthorized {
+			t.Fatalf("Authorization %q: status %d, want 401", authorization, status)
+		}
+	}
+	if status, body := post(t, server.URL, "Bearer "+testToken, call); status != http.StatusOK || body != `{"jsonrpc":"2.0","id":1,"result":"hi"}` {
+		t.Fatalf("authorized call: %d %s", status, body)
+	}
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET: status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, server := newTestServer(t)
+
+	// want is a prefix, so messages from encoding/json are not matched in full
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"missing"}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: missing"}}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"echo","params":{"text":3}}`,
+			`{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"invalid params: json: cannot unmarshal number`},
+		{`{"jsonrpc":"2.0","id":3,"method":"echo"}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"text is required"}}`},
+		{`{"jsonrpc":"2.0","id":"a","method":"fail"}`,
+			`{"jsonrpc":"2.0","id":"a","error":{"code":-32603,"message":"boom"}}`},
+		{`{"id":4,"method":"echo"}`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`},
+		{`{"jsonrpc":`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`},
+		{`[]`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"invalid request"}}`},
+	}
+	for _, tc := range cases {
+		status, body := post(t, server.URL, "Bearer "+testToken, tc.body)
+		if status != http.StatusOK || !strings.HasPrefix(body, tc.want) {
+			t.Errorf("%s:\ngot  %d %s\nwant %s", tc.body, status, body, tc.want)
+		}
+	}
+}
+
+func TestBatchAndNotifications(t *testing.T) {
+	_, server := newTestServer(t)
+
+	status, body := post(t, server.URL, "Bearer "+testToken, `[
+		{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"one"}},
+		{"jsonrpc":"2.0","method":"echo","params":{"text":"notified"}},
+		{"jsonrpc":"2.0","id":2,"method":"missing"},
+		{"jsonrpc":"2.0","id":3,"method":"echo","params":{"text":"three"}}
+	]`)
+	want := `[{"jsonrpc":"2.0","id":1,"result":"one"},` +
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method not found: missing"}},` +
+		`{"jsonrpc":"2.0","id":3,"result":"three"}]`
+	if status != http.StatusOK || body != want {
+		t.Fatalf("batch:\ngot  %d %s\nwant %s", status, body, want)
+	}
+
+	// Notifications alone get no response body, even when they fail
+	for _, notification := range []string{
+		`{"jsonrpc":"2.0","method":"echo","params":{"text":"hi"}}`,
+		`{"jsonrpc":"2.0","method":"fail"}`,
+		`[{"jsonrpc":"2.0","method":"echo","params":{"text":"a"}},{"jsonrpc":"2.0","method":"missing"}]`,
+	} {
+		if status, body := post(t, server.URL, "Bearer "+testToken, notification); status != http.StatusNoContent || body != "" {
+			t.Fatalf("%s: %d %q, want 204 without body", notification, status, body)
+		}
+	}
+}
+
+func TestStartRefusesLiveSocket(t *testing.T) {
+	if gosruntime.GOOS != "linux" {
+		t.Skip("the control server uses a Unix socket on Linux only")
+	}
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "control.sock")
+
+	// A socket left behind by a crashed instance is replaced
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	first := NewServer(ServerOptions{EndpointFile: filepath.Join(dir, "first.json"), SocketPath: socketPath})
+	first.Register("ping", func(json.RawMessage) (interface{}, error) { return "pong", nil })
+	if err := first.Start(); err != nil {
+		t.Fatalf("start over a stale socket: %v", err)
+	}
+	defer first.Stop()
+
+	second := NewServer(ServerOptions{EndpointFile: filepath.Join(dir, "second.json"), SocketPath: socketPath})
+	if err := second.Start(); err == nil {
+		second.Stop()
+		t.Fatal("second server started on a live socket")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "second.json")); !os.IsNotExist(err) {
+		t.Fatalf("second server wrote its endpoint file: %v", err)
+	}
+
+	// The first server is still reachable
+	client, err := Dial(filepath.Join(dir, "first.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result string
+	if err := client.Call("ping", nil, &result); err != nil || result != "pong" {
+		t.Fatalf("ping = %q, %v", result, err)
+	}
+}
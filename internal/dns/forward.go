@@ -0,0 +1,167 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// resolvConfPath is where Unix systems list their nameservers
+const resolvConfPath = "/etc/resolv.conf"
+
+// forwarder relays queries for non-development names to the system resolver
+type forwarder struct {
+	upstream string
+}
+
+// newForwarder creates a forwarder for upstream, falling back to the system nameserver.
+// The server's own address is skipped so it never forwards queries to itself.
+func newForwarder(upstream, self string) *forwarder {
+	if upstream == "" {
+		upstream = systemNameserver(self)
+	}
+	return &forwarder{upstream: upstream}
+}
+
+// exchange forwards a raw query and returns the raw upstream response
+func (f *forwarder) exchange(query []byte, header dnsmessage.Header, question dnsmessage.Question, overTCP bool) ([]byte, error) {
+	if f.upstream == "" {
+		// No nameserver known (e.g. Windows), use the Go resolver which asks the OS
+		return resolveWithSystem(header, question)
+	}
+
+	if !overTCP {
+		response, err := f.exchangeUDP(query)
+		if err != nil {
+			return nil, err
+		}
+		// Retry over TCP when the upstream truncated the answer
+		if len(response) < 3 || response[2]&0x02 == 0 {
+			return response, nil
+		}
+	}
+	return f.exchangeTCP(query)
+}
+
+func (f *forwarder) exchangeUDP(query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", f.upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(upstreamTimeout))
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (f *forwarder) exchangeTCP(query []byte) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", f.upstream, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(upstreamTimeout))
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, err
+	}
+	return readTCPMessage(conn)
+}
+
+// resolveWithSystem answers A and AAAA queries through net.DefaultResolver
+func resolveWithSystem(header dnsmessage.Header, question dnsmessage.Question) ([]byte, error) {
+	network := ""
+	switch question.Type {
+	case dnsmessage.TypeA:
+		network = "ip4"
+	case dnsmessage.TypeAAAA:
+		network = "ip6"
+	default:
+		return nil, fmt.Errorf("no upstream nameserver for %s queries", question.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upstreamTimeout)
+	defer cancel()
+
+	rcode := dnsmessage.RCodeSuccess
+	ips, err := net.DefaultResolver.LookupIP(ctx, network, question.Name.String())
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			rcode = dnsmessage.RCodeNameError
+		} else {
+			return nil, err
+		}
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	builder.EnableCompression()
+	builder.StartQuestions()
+	builder.Question(question)
+	builder.StartAnswers()
+
+	resource := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   defaultTTL,
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil && question.Type == dnsmessage.TypeA {
+			var a [4]byte
+			copy(a[:], ip4)
+			builder.AResource(resource, dnsmessage.AResource{A: a})
+		} else if ip4 == nil && question.Type == dnsmessage.TypeAAAA {
+			var aaaa [16]byte
+			copy(aaaa[:], ip.To16())
+			builder.AAAAResource(resource, dnsmessage.AAAAResource{AAAA: aaaa})
+		}
+	}
+
+	return builder.Finish()
+}
+
+// systemNameserver returns the first nameserver from resolv.conf that is not the server itself
+func systemNameserver(self string) string {
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	selfHost, selfPort, _ := net.SplitHostPort(self)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		host := fields[1]
+		if host == selfHost && selfPort == "53" {
+			continue
+		}
+		return net.JoinHostPort(host, "53")
+	}
+	return ""
+}
@@ -0,0 +1,330 @@
+// Package dns implements a small DNS server that resolves wildcard development domains to loopback.
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	defaultTTL      = 60
+	readTimeout     = 5 * time.Second
+	upstreamTimeout = 5 * time.Second
+)
+
+// ServerOptions configures a development DNS server
+type ServerOptions struct {
+	// Addr is the loopback host:port to listen on for both UDP and TCP (port 0 picks a free port)
+	Addr string
+	// Domains are the suffixes answered locally, e.g. "test" matches *.test
+	Domains []string
+	// Upstream is the resolver used for all other names; empty means the system resolver
+	Upstream string
+}
+
+// Server answers development domains with loopback addresses and forwards everything else
+type Server struct {
+	opts      ServerOptions
+	domains   []string
+	forwarder *forwarder
+	udpConn   net.PacketConn
+	tcpLn     net.Listener
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+	isRunning bool
+}
+
+// NewServer creates a new DNS server with the specified options
+func NewServer(opts ServerOptions) *Server {
+	domains := make([]string, 0, len(opts.Domains))
+	for _, domain := range opts.Domains {
+		domain = strings.Trim(strings.ToLower(domain), ".")
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	return &Server{
+		opts:      opts,
+		domains:   domains,
+		forwarder: newForwarder(opts.Upstream, opts.Addr),
+	}
+}
+
+// Start binds the UDP and TCP listeners and begins serving queries
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isRunning {
+		return fmt.Errorf("dns server is already running")
+	}
+	if err := checkLoopback(s.opts.Addr); err != nil {
+		return err
+	}
+
+	udpConn, err := net.ListenPacket("udp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", s.opts.Addr, err)
+	}
+
+	// Bind TCP to the same port UDP received, so port 0 works for both
+	tcpLn, err := net.Listen("tcp", udpConn.LocalAddr().String())
+	if err != nil {
+		udpConn.Close()
+		return fmt.Errorf("failed to listen on tcp %s: %w", s.opts.Addr, err)
+	}
+
+	s.udpConn = udpConn
+	s.tcpLn = tcpLn
+	s.isRunning = true
+
+	s.wg.Add(2)
+	go s.serveUDP(udpConn)
+	go s.serveTCP(tcpLn)
+
+	return nil
+}
+
+// Stop closes the listeners and waits for in-flight queries to finish
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	if !s.isRunning {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.isRunning = false
+	s.udpConn.Close()
+	s.tcpLn.Close()
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// IsRunning returns whether the server is serving queries
+func (s *Server) IsRunning() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isRunning
+}
+
+// Addr returns the address the server is bound to
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.udpConn == nil {
+		return s.opts.Addr
+	}
+	return s.udpConn.LocalAddr().String()
+}
+
+// checkLoopback rejects listen addresses reachable from other machines. The server forwards every
+// name outside the development domains, so on a LAN address it would be an open resolver.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid dns listen address %q: %w", addr, err)
+	}
+	if strings.EqualFold(host, "localhost") {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("dns server must listen on a loopback address, not %q", host)
+}
+
+// serveUDP handles datagram queries until the connection is closed
+func (s *Server) serveUDP(conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		query := make([]byte, n)
+		copy(query, buf[:n])
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			response := s.handle(query, false)
+			if response != nil {
+				conn.WriteTo(response, addr)
+			}
+		}()
+	}
+}
+
+// serveTCP accepts stream connections until the listener is closed
+func (s *Server) serveTCP(ln net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleTCPConn(conn)
+		}()
+	}
+}
+
+// handleTCPConn serves length-prefixed queries on a single TCP connection
+func (s *Server) handleTCPConn(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+
+		response := s.handle(query, true)
+		if response == nil {
+			return
+		}
+		if err := writeTCPMessage(conn, response); err != nil {
+			return
+		}
+	}
+}
+
+// handle answers a raw DNS query, returning nil when the query is unparseable
+func (s *Server) handle(query []byte, overTCP bool) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return s.errorResponse(header, nil, dnsmessage.RCodeFormatError)
+	}
+
+	if !s.isLocal(question.Name.String()) {
+		response, err := s.forwarder.exchange(query, header, question, overTCP)
+		if err != nil {
+			log.Printf("DNS forward for %s failed: %v", question.Name.String(), err)
+			return s.errorResponse(header, &question, dnsmessage.RCodeServerFailure)
+		}
+		return response
+	}
+
+	return s.localResponse(header, question)
+}
+
+// isLocal reports whether name falls under one of the development domains
+func (s *Server) isLocal(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, domain := range s.domains {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// localResponse answers A and AAAA queries for development domains with loopback addresses
+func (s *Server) localResponse(header dnsmessage.Header, question dnsmessage.Question) []byte {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+	})
+	builder.EnableCompression()
+	builder.StartQuestions()
+	builder.Question(question)
+	builder.StartAnswers()
+
+	resource := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   defaultTTL,
+	}
+
+	switch question.Type {
+	case dnsmessage.TypeA:
+		builder.AResource(resource, dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}})
+	case dnsmessage.TypeAAAA:
+		builder.AAAAResource(resource, dnsmessage.AAAAResource{AAAA: [16]byte{15: 1}})
+	}
+
+	response, err := builder.Finish()
+	if err != nil {
+		return nil
+	}
+	return response
+}
+
+// errorResponse builds a response carrying only an error code
+func (s *Server) errorResponse(header dnsmessage.Header, question *dnsmessage.Question, rcode dnsmessage.RCode) []byte {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              rcode,
+	})
+	builder.StartQuestions()
+	if question != nil {
+		builder.Question(*question)
+	}
+
+	response, err := builder.Finish()
+	if err != nil {
+		return nil
+	}
+	return response
+}
+
+// readTCPMessage reads a two-byte length-prefixed DNS message
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeTCPMessage writes a two-byte length-prefixed DNS message
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,271 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func startServer(t *testing.T, opts ServerOptions) *Server {
+	t.Helper()
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:0"
+	}
+	server := NewServer(opts)
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Stop() })
+	return server
+}
+
+func buildQuery(t *testing.T, id uint16, name string, qtype dnsmessage.Type) []byte {
+	t.Helper()
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	builder.StartQuestions()
+	if err := builder.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  qtype,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	query, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query
+}
+
+func exchange(t *testing.T, network, addr string, query []byte) []byte {
+	t.Helper()
+	conn, err := net.DialTimeout(network, addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	if network == "tcp" {
+		if err := writeTCPMessage(conn, query); err != nil {
+			t.Fatal(err)
+		}
+		response, err := readTCPMessage(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+func parseResponse(t *testing.T, response []byte) dnsmessage.Message {
+	t.Helper()
+	var msg dnsmessage.Message
+	if err := msg.Unpack(response); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestLocalAnswers(t *testing.T) {
+	server := startServer(t, ServerOptions{Domains: []string{"test"}})
+
+	for _, network := range []string{"udp", "tcp"} {
+		msg := parseResponse(t, exchange(t, network, server.Addr(), buildQuery(t, 1, "app.test.", dnsmessage.TypeA)))
+		if msg.ID != 1 || !msg.Authoritative || msg.RCode != dnsmessage.RCodeSuccess || len(msg.Answers) != 1 {
+			t.Fatalf("%s A: unexpected response %+v", network, msg.Header)
+		}
+		if a, ok := msg.Answers[0].Body.(*dnsmessage.AResource); !ok || a.A != [4]byte{127, 0, 0, 1} {
+			t.Fatalf("%s A: answer %v", network, msg.Answers[0].Body)
+		}
+
+		msg = parseResponse(t, exchange(t, network, server.Addr(), buildQuery(t, 2, "api.shop.TEST.", dnsmessage.TypeAAAA)))
+		if len(msg.Answers) != 1 {
+			t.Fatalf("%s AAAA: %d answers", network, len(msg.Answers))
+		}
+		if aaaa, ok := msg.Answers[0].Body.(*dnsmessage.AAAAResource); !ok || aaaa.AAAA != [16]byte{15: 1} {
+			t.Fatalf("%s AAAA: answer %v", network, msg.Answers[0].Body)
+		}
+
+		// Other types under a development domain get an empty answer rather than a forward
+		msg = parseResponse(t, exchange(t, network, server.Addr(), buildQuery(t, 3, "app.test.", dnsmessage.TypeMX)))
+		if msg.RCode != dnsmessage.RCodeSuccess || len(msg.Answers) != 0 {
+			t.Fatalf("%s MX: %+v with %d answers", network, msg.Header, len(msg.Answers))
+		}
+	}
+}
+
+// startUpstream runs a stub resolver that knows only example.com, on one port for UDP and TCP
+func startUpstream(t *testing.T) string {
+	t.Helper()
+	stub := &Server{}
+	answer := func(query []byte) []byte {
+		var parser dnsmessage.Parser
+		header, err := parser.Start(query)
+		if err != nil {
+			return nil
+		}
+		question, err := parser.Question()
+		if err != nil {
+			return nil
+		}
+		if question.Name.String() != "example.com." {
+			return stub.errorResponse(header, &question, dnsmessage.RCodeNameError)
+		}
+		builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true})
+		builder.StartQuestions()
+		builder.Question(question)
+		builder.StartAnswers()
+		builder.AResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 30},
+			dnsmessage.AResource{A: [4]byte{192, 0, 2, 7}})
+		response, _ := builder.Finish()
+		return response
+	}
+
+	udpConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpLn, err := net.Listen("tcp", udpConn.LocalAddr().String())
+	if err != nil {
+		udpConn.Close()
+		t.Skipf("cannot bind stub upstream over tcp: %v", err)
+	}
+	t.Cleanup(func() {
+		udpConn.Close()
+		tcpLn.Close()
+	})
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := udpConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if response := answer(buf[:n]); response != nil {
+				udpConn.WriteTo(response, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcpLn.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := readTCPMessage(conn)
+				if err != nil {
+					return
+				}
+				if response := answer(query); response != nil {
+					writeTCPMessage(conn, response)
+				}
+			}()
+		}
+	}()
+	return udpConn.LocalAddr().String()
+}
+
+func TestForwarding(t *testing.T) {
+	server := startServer(t, ServerOptions{Domains: []string{"test"}, Upstream: startUpstream(t)})
+
+	for _, network := range []string{"udp", "tcp"} {
+		msg := parseResponse(t, exchange(t, network, server.Addr(), buildQuery(t, 10, "example.com.", dnsmessage.TypeA)))
+		if msg.ID != 10 || len(msg.Answers) != 1 {
+			t.Fatalf("%s forward: %+v with %d answers", network, msg.Header, len(msg.Answers))
+		}
+		if a, ok := msg.Answers[0].Body.(*dnsmessage.AResource); !ok || a.A != [4]byte{192, 0, 2, 7} {
+			t.Fatalf("%s forward: answer %v", network, msg.Answers[0].Body)
+		}
+
+		msg = parseResponse(t, exchange(t, network, server.Addr(), buildQuery(t, 11, "missing.example.", dnsmessage.TypeA)))
+		if msg.RCode != dnsmessage.RCodeNameError {
+			t.Fatalf("%s missing name: rcode %v, want NXDOMAIN", network, msg.RCode)
+		}
+	}
+}
+
+func TestUpstreamFailure(t *testing.T) {
+	// Nothing listens on the upstream port, so forwarding fails
+	ln, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := ln.LocalAddr().String()
+	ln.Close()
+
+	server := startServer(t, ServerOptions{Domains: []string{"test"}, Upstream: upstream})
+	msg := parseResponse(t, exchange(t, "tcp", server.Addr(), buildQuery(t, 12, "example.com.", dnsmessage.TypeA)))
+	if msg.RCode != dnsmessage.RCodeServerFailure {
+		t.Fatalf("rcode %v, want SERVFAIL", msg.RCode)
+	}
+}
+
+func TestMalformedPackets(t *testing.T) {
+	server := startServer(t, ServerOptions{Domains: []string{"test"}})
+
+	// Too short for a header: no response is sent
+	conn, err := net.Dial("udp", server.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte{0x01, 0x02, 0x03})
+	conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 512)); err == nil {
+		t.Fatal("got a response to a truncated header")
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("read error %v, want timeout", err)
+	}
+
+	// A header announcing a question that is missing gets FORMERR with the same ID
+	header := []byte{0xab, 0xcd, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff}
+	for _, network := range []string{"udp", "tcp"} {
+		msg := parseResponse(t, exchange(t, network, server.Addr(), header))
+		if msg.ID != 0xabcd || msg.RCode != dnsmessage.RCodeFormatError {
+			t.Fatalf("%s: %+v, want FORMERR", network, msg.Header)
+		}
+	}
+
+	// The server keeps answering after bad input
+	msg := parseResponse(t, exchange(t, "udp", server.Addr(), buildQuery(t, 20, "app.test.", dnsmessage.TypeA)))
+	if len(msg.Answers) != 1 {
+		t.Fatalf("%d answers after malformed packets", len(msg.Answers))
+	}
+}
+
+func TestRejectsNonLoopbackAddress(t *testing.T) {
+	for _, addr := range []string{"0.0.0.0:0", "192.168.1.10:5353", "[::]:0", "example.com:53"} {
+		err := NewServer(ServerOptions{Addr: addr}).Start()
+		if err == nil {
+			t.Fatalf("%s: server started on a non-loopback address", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+		server := NewServer(ServerOptions{Addr: addr})
+		if err := server.Start(); err != nil {
+			var opErr *net.OpError
+			if errors.As(err, &opErr) {
+				t.Skipf("%s: %v", addr, err)
+			}
+			t.Fatalf("%s: %v", addr, err)
+		}
+		server.Stop()
+	}
+}
@@ -0,0 +1,17 @@
+// Package events decouples the backend from the Wails runtime. Packages emit and subscribe
+// through a Bus, which is backed by Wails in the GUI (see the wailsbus package) and by a
+// MemoryBus headless and in tests.
+package events
+
+// Handler receives the data passed to Emit
+type Handler func(data ...interface{})
+
+// Bus delivers events by topic
+type Bus interface {
+	// Emit sends data to every subscriber of topic
+	Emit(topic string, data ...interface{})
+	// Subscribe registers handler for topic and returns a function that removes it
+	Subscribe(topic string, handler Handler) (unsubscribe func())
+	// Unsubscribe removes every handler registered for topic
+	Unsubscribe(topic string)
+}
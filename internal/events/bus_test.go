@@ -0,0 +1,90 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMemoryBusEmitAndSubscribe(t *testing.T) {
+	bus := NewMemoryBus()
+	var got []string
+	bus.Subscribe("download", func(data ...interface{}) {
+		got = append(got, "first:"+data[0].(string))
+	})
+	unsubscribe := bus.Subscribe("download", func(data ...interface{}) {
+		got = append(got, "second:"+data[0].(string))
+	})
+	bus.Subscribe("other", func(data ...interface{}) {
+		got = append(got, "other")
+	})
+
+	bus.Emit("download", "php")
+	unsubscribe()
+	// Calling it again must not remove another handler
+	unsubscribe()
+	bus.Emit("download", "mysql")
+	bus.Emit("nobody-listens")
+
+	want := []string{"first:php", "second:php", "first:mysql"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("handlers saw %v, want %v", got, want)
+	}
+}
+
+func TestMemoryBusUnsubscribeTopic(t *testing.T) {
+	bus := NewMemoryBus()
+	calls := 0
+	bus.Subscribe("cancel", func(...interface{}) { calls++ })
+	bus.Subscribe("cancel", func(...interface{}) { calls++ })
+
+	bus.Unsubscribe("cancel")
+	bus.Emit("cancel")
+	if calls != 0 {
+		t.Fatalf("%d handlers ran after Unsubscribe", calls)
+	}
+	if len(bus.handlers) != 0 {
+		t.Fatalf("topics left: %v", bus.handlers)
+	}
+}
+
+func TestMemoryBusOnce(t *testing.T) {
+	bus := NewMemoryBus()
+	calls := 0
+	// A handler may unsubscribe itself while being called, so it runs for one event only
+	var unsubscribe func()
+	unsubscribe = bus.Subscribe("finish", func(...interface{}) {
+		calls++
+		unsubscribe()
+	})
+	// Handlers added during Emit only see later events
+	bus.Subscribe("finish", func(...interface{}) {
+		bus.Subscribe("finish", func(...interface{}) { calls += 10 })
+	})
+
+	bus.Emit("finish")
+	bus.Emit("finish")
+	if calls != 11 {
+		t.Fatalf("calls = %d, want 11", calls)
+	}
+}
+
+func TestMemoryBusConcurrentUse(t *testing.T) {
+	bus := NewMemoryBus()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				unsubscribe := bus.Subscribe("progress", func(...interface{}) {})
+				bus.Emit("progress", j)
+				unsubscribe()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(bus.handlers) != 0 {
+		t.Fatalf("topics left: %v", bus.handlers)
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import "sync"
+
+// MemoryBus is an in-process Bus that calls handlers synchronously in subscription order
+type MemoryBus struct {
+	mutex    sync.RWMutex
+	nextID   int
+	handlers map[string][]subscription
+}
+
+type subscription struct {
+	id      int
+	handler Handler
+}
+
+// NewMemoryBus creates an empty in-memory bus
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{handlers: make(map[string][]subscription)}
+}
+
+// Emit calls every handler of topic with data
+func (b *MemoryBus) Emit(topic string, data ...interface{}) {
+	b.mutex.RLock()
+	subscriptions := append([]subscription(nil), b.handlers[topic]...)
+	b.mutex.RUnlock()
+
+	for _, sub := range subscriptions {
+		sub.handler(data...)
+	}
+}
+
+// Subscribe registers handler for topic
+func (b *MemoryBus) Subscribe(topic string, handler Handler) func() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	b.handlers[topic] = append(b.handlers[topic], subscription{id: id, handler: handler})
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		subscriptions := b.handlers[topic]
+		for i, sub := range subscriptions {
+			if sub.id == id {
+				b.handlers[topic] = append(subscriptions[:i:i], subscriptions[i+1:]...)
+				break
+			}
+		}
+		if len(b.handlers[topic]) == 0 {
+			delete(b.handlers, topic)
+		}
+	}
+}
+
+// Unsubscribe removes every handler of topic
+func (b *MemoryBus) Unsubscribe(topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.handlers, topic)
+}
@@ -0,0 +1,94 @@
+// Package wailsbus implements events.Bus on the Wails runtime. It lives apart from events so
+// that the CLI and other headless code can use the bus without linking Wails.
+package wailsbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/JadlionHD/Enty/internal/events"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Bus forwards events to the Wails runtime, reaching both the frontend and Go listeners.
+// It needs the application context, so events emitted before Start are dropped and
+// subscriptions made before Start are registered once it is called.
+type Bus struct {
+	mutex   sync.Mutex
+	ctx     context.Context
+	pending []*subscription
+}
+
+type subscription struct {
+	topic     string
+	handler   events.Handler
+	cancelled bool
+	cancel    func()
+}
+
+// New creates a bus that becomes active once Start is called
+func New() *Bus {
+	return &Bus{}
+}
+
+// Start binds the bus to the Wails application context
+func (b *Bus) Start(ctx context.Context) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.ctx = ctx
+	for _, sub := range b.pending {
+		if !sub.cancelled {
+			sub.cancel = runtime.EventsOn(ctx, sub.topic, sub.handler)
+		}
+	}
+	b.pending = nil
+}
+
+// Emit sends data to the frontend and Go listeners of topic
+func (b *Bus) Emit(topic string, data ...interface{}) {
+	b.mutex.Lock()
+	ctx := b.ctx
+	b.mutex.Unlock()
+
+	if ctx != nil {
+		runtime.EventsEmit(ctx, topic, data...)
+	}
+}
+
+// Subscribe registers handler for topic, including events emitted by the frontend
+func (b *Bus) Subscribe(topic string, handler events.Handler) func() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub := &subscription{topic: topic, handler: handler}
+	if b.ctx != nil {
+		sub.cancel = runtime.EventsOn(b.ctx, topic, handler)
+	} else {
+		b.pending = append(b.pending, sub)
+	}
+
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		sub.cancelled = true
+		if sub.cancel != nil {
+			sub.cancel()
+		}
+	}
+}
+
+// Unsubscribe removes every listener of topic
+func (b *Bus) Unsubscribe(topic string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, sub := range b.pending {
+		if sub.topic == topic {
+			sub.cancelled = true
+		}
+	}
+	if b.ctx != nil {
+		runtime.EventsOff(b.ctx, topic)
+	}
+}
@@ -0,0 +1,380 @@
+// Package hosts manages an Enty-owned block of entries inside the system hosts file.
+package hosts
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	BlockBegin = "# BEGIN ENTY"
+	BlockEnd   = "# END ENTY"
+
+	// PathEnv overrides the hosts file location, mainly for testing
+	PathEnv = "ENTY_HOSTS_FILE"
+)
+
+// ErrElevationRequired is returned when the hosts file cannot be written without administrator rights
+var ErrElevationRequired = errors.New("administrator privileges are required to modify the hosts file")
+
+// ErrUnterminatedBlock is returned when the hosts file has a begin marker without an end marker,
+// so Enty cannot tell which lines it owns
+var ErrUnterminatedBlock = errors.New("hosts file has " + BlockBegin + " without " + BlockEnd + "; remove or complete the block by hand")
+
+// Entry maps a hostname to an IP address
+type Entry struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+}
+
+// Manager reads and writes the Enty block of a hosts file, leaving all other lines untouched
+type Manager struct {
+	path       string
+	backupPath string
+	mutex      sync.Mutex
+}
+
+// DefaultPath returns the hosts file path for the current platform, honoring ENTY_HOSTS_FILE
+func DefaultPath() string {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+
+	if gosruntime.GOOS == "windows" {
+		root := os.Getenv("SystemRoot")
+		if root == "" {
+			root = `C:\Windows`
+		}
+		return filepath.Join(root, "System32", "drivers", "etc", "hosts")
+	}
+	return "/etc/hosts"
+}
+
+// NewManager creates a manager for the hosts file at path.
+// The original file is copied to backupPath before the first modification.
+func NewManager(path, backupPath string) *Manager {
+	return &Manager{
+		path:       path,
+		backupPath: backupPath,
+	}
+}
+
+// Path returns the managed hosts file path
+func (m *Manager) Path() string {
+	return m.path
+}
+
+// Entries returns the entries currently inside the Enty block
+func (m *Manager) Entries() ([]Entry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	content, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+
+	_, block, _, err := splitBlock(content)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntries(block), nil
+}
+
+// Add adds or updates the entry for a hostname
+func (m *Manager) Add(entry Entry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, err := normalizeEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	content, err := m.read()
+	if err != nil {
+		return err
+	}
+
+	_, block, _, err := splitBlock(content)
+	if err != nil {
+		return err
+	}
+	entries := parseEntries(block)
+
+	replaced := false
+	for i, existing := range entries {
+		if existing.Hostname == entry.Hostname && isIPv6(existing.IP) == isIPv6(entry.IP) {
+			entries[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		entries = append(entries, entry)
+	}
+
+	return m.write(content, entries)
+}
+
+// Remove removes all entries for a hostname
+func (m *Manager) Remove(hostname string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	hostname = strings.ToLower(strings.TrimSpace(hostname))
+
+	content, err := m.read()
+	if err != nil {
+		return err
+	}
+
+	_, block, _, err := splitBlock(content)
+	if err != nil {
+		return err
+	}
+	var entries []Entry
+	for _, entry := range parseEntries(block) {
+		if entry.Hostname != hostname {
+			entries = append(entries, entry)
+		}
+	}
+
+	return m.write(content, entries)
+}
+
+// SetEntries replaces the whole Enty block with the given entries
+func (m *Manager) SetEntries(entries []Entry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	normalized := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		entry, err := normalizeEntry(entry)
+		if err != nil {
+			return err
+		}
+		normalized = append(normalized, entry)
+	}
+
+	content, err := m.read()
+	if err != nil {
+		return err
+	}
+	return m.write(content, normalized)
+}
+
+// read returns the hosts file content (assumes lock is held)
+func (m *Manager) read() (string, error) {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", wrapPermission(m.path, err)
+	}
+	return string(data), nil
+}
+
+// write replaces the Enty block, keeping every byte outside it as it is (assumes lock is held)
+func (m *Manager) write(content string, entries []Entry) error {
+	before, _, after, err := splitBlock(content)
+	if err != nil {
+		return err
+	}
+
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(before)
+	if len(entries) > 0 {
+		// A new block starts on its own line at the end of the file
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			sb.WriteString(newline)
+		}
+		sb.WriteString(BlockBegin + newline)
+		for _, entry := range sortEntries(entries) {
+			sb.WriteString(entry.IP + "\t" + entry.Hostname + newline)
+		}
+		sb.WriteString(BlockEnd + newline)
+	}
+	sb.WriteString(after)
+
+	// Leave the file alone, without asking for elevation, when nothing changed
+	if sb.String() == content {
+		return nil
+	}
+	if err := m.backup(content); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(m.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := replaceFile(m.path, []byte(sb.String()), mode); err != nil {
+		return wrapPermission(m.path, err)
+	}
+	return nil
+}
+
+// replaceFile writes data to a temporary file next to path and renames it over path, so readers
+// never see a partly written file. Where that is not possible, such as a hosts file bind-mounted
+// into a container or a directory Enty cannot write to, the file is written in place.
+func replaceFile(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".enty-hosts-*")
+	if err == nil {
+		name := tmp.Name()
+		_, err = tmp.Write(data)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Chmod(name, mode)
+		}
+		if err == nil {
+			err = os.Rename(name, path)
+		}
+		if err == nil {
+			return nil
+		}
+		os.Remove(name)
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+// backup copies the original hosts file once, before Enty modifies it for the first time
+func (m *Manager) backup(content string) error {
+	if m.backupPath == "" {
+		return nil
+	}
+	if _, err := os.Stat(m.backupPath); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.backupPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create hosts backup directory: %w", err)
+	}
+	if err := os.WriteFile(m.backupPath, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to back up hosts file: %w", err)
+	}
+	return nil
+}
+
+// splitBlock splits content into the text before, inside and after the Enty block. The marker
+// lines belong to the block, so before and after are exactly the bytes Enty must not touch.
+// Markers only count as whole lines, so comments that merely contain them are left alone.
+func splitBlock(content string) (before, block, after string, err error) {
+	start, blockStart := findMarker(content, 0, BlockBegin)
+	if start == -1 {
+		return content, "", "", nil
+	}
+
+	end, afterStart := findMarker(content, blockStart, BlockEnd)
+	if end == -1 {
+		// Never guess where the block ends, as that could remove the user's own entries
+		return "", "", "", ErrUnterminatedBlock
+	}
+	return content[:start], content[blockStart:end], content[afterStart:], nil
+}
+
+// findMarker returns the offset of the first line at or after offset that is marker, ignoring
+// surrounding whitespace, and the offset of the line that follows it; -1 when there is none
+func findMarker(content string, offset int, marker string) (lineStart, next int) {
+	for offset < len(content) {
+		line, next := content[offset:], len(content)
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line, next = line[:i], offset+i+1
+		}
+		if strings.TrimSpace(line) == marker {
+			return offset, next
+		}
+		offset = next
+	}
+	return -1, -1
+}
+
+// parseEntries parses "ip hostname..." lines, ignoring comments and blanks
+func parseEntries(block string) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(block, "\n") {
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, hostname := range fields[1:] {
+			entries = append(entries, Entry{IP: fields[0], Hostname: strings.ToLower(hostname)})
+		}
+	}
+	return entries
+}
+
+// normalizeEntry validates an entry and returns it in canonical form
+func normalizeEntry(entry Entry) (Entry, error) {
+	ip := net.ParseIP(strings.TrimSpace(entry.IP))
+	if ip == nil {
+		return entry, fmt.Errorf("invalid IP address: %q", entry.IP)
+	}
+
+	hostname := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry.Hostname)), ".")
+	if err := ValidateHostname(hostname); err != nil {
+		return entry, err
+	}
+
+	return Entry{IP: ip.String(), Hostname: hostname}, nil
+}
+
+// ValidateHostname checks that hostname is a valid DNS name (wildcards are not supported by hosts files)
+func ValidateHostname(hostname string) error {
+	if hostname == "" || len(hostname) > 253 {
+		return fmt.Errorf("invalid hostname: %q", hostname)
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("invalid hostname: %q", hostname)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("invalid hostname: %q", hostname)
+			}
+		}
+	}
+	return nil
+}
+
+func sortEntries(entries []Entry) []Entry {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Hostname != sorted[j].Hostname {
+			return sorted[i].Hostname < sorted[j].Hostname
+		}
+		return sorted[i].IP < sorted[j].IP
+	})
+	return sorted
+}
+
+func isIPv6(ip string) bool {
+	return strings.Contains(ip, ":")
+}
+
+// wrapPermission turns permission errors into ErrElevationRequired with the offending path
+func wrapPermission(path string, err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return fmt.Errorf("%w: %s", ErrElevationRequired, path)
+	}
+	return fmt.Errorf("failed to access hosts file: %w", err)
+}
@@ -0,0 +1,143 @@
+package hosts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestManager(t *testing.T, content string) (*Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return NewManager(path, filepath.Join(dir, "hosts.bak")), path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestAddKeepsLinesOutsideBlock(t *testing.T) {
+	original := "127.0.0.1   localhost  \n# my comment\n\n\n10.0.0.1 nas.lan"
+	m, path := newTestManager(t, original)
+
+	if err := m.Add(Entry{IP: "127.0.0.1", Hostname: "app.test"}); err != nil {
+		t.Fatal(err)
+	}
+	want := original + "\n" + BlockBegin + "\n127.0.0.1\tapp.test\n" + BlockEnd + "\n"
+	if got := readFile(t, path); got != want {
+		t.Fatalf("after add:\n%q\nwant\n%q", got, want)
+	}
+
+	if err := m.Remove("app.test"); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != original+"\n" {
+		t.Fatalf("after remove:\n%q\nwant\n%q", got, original+"\n")
+	}
+}
+
+func TestReplaceBlockInMiddle(t *testing.T) {
+	before := "127.0.0.1 localhost\r\n\r\n"
+	after := "  # trailing user line\r\n10.0.0.2 printer\r\n"
+	m, path := newTestManager(t, before+BlockBegin+"\r\n127.0.0.1\told.test\r\n"+BlockEnd+"\r\n"+after)
+
+	if err := m.SetEntries([]Entry{{IP: "::1", Hostname: "new.test"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := before + BlockBegin + "\r\n::1\tnew.test\r\n" + BlockEnd + "\r\n" + after
+	if got := readFile(t, path); got != want {
+		t.Fatalf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestUnterminatedBlockIsNotTouched(t *testing.T) {
+	original := "127.0.0.1 localhost\n" + BlockBegin + "\n127.0.0.1 app.test\n10.0.0.1 nas.lan\n"
+	m, path := newTestManager(t, original)
+
+	if _, err := m.Entries(); !errors.Is(err, ErrUnterminatedBlock) {
+		t.Fatalf("Entries error = %v, want ErrUnterminatedBlock", err)
+	}
+	if err := m.Add(Entry{IP: "127.0.0.1", Hostname: "other.test"}); !errors.Is(err, ErrUnterminatedBlock) {
+		t.Fatalf("Add error = %v, want ErrUnterminatedBlock", err)
+	}
+	if err := m.SetEntries(nil); !errors.Is(err, ErrUnterminatedBlock) {
+		t.Fatalf("SetEntries error = %v, want ErrUnterminatedBlock", err)
+	}
+	if got := readFile(t, path); got != original {
+		t.Fatalf("file changed:\n%q", got)
+	}
+}
+
+func TestMarkersMatchWholeLines(t *testing.T) {
+	original := "127.0.0.1 localhost\n# BEGIN ENTYRE network\n10.0.0.1 nas.lan # BEGIN ENTY\n  " + BlockBegin + "  \n127.0.0.1\told.test\n10.0.0.9 printer # END ENTY\n" + BlockEnd + "\n# END ENTYRE\n"
+	m, path := newTestManager(t, original)
+
+	entries, err := m.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The printer line is inside the block, as its marker text is not on a line of its own
+	if len(entries) != 2 || entries[0].Hostname != "old.test" || entries[1].Hostname != "printer" {
+		t.Fatalf("entries %+v", entries)
+	}
+
+	if err := m.SetEntries([]Entry{{IP: "127.0.0.1", Hostname: "new.test"}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "127.0.0.1 localhost\n# BEGIN ENTYRE network\n10.0.0.1 nas.lan # BEGIN ENTY\n" + BlockBegin + "\n127.0.0.1\tnew.test\n" + BlockEnd + "\n# END ENTYRE\n"
+	if got := readFile(t, path); got != want {
+		t.Fatalf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestLookalikeMarkerIsNotABlock(t *testing.T) {
+	original := "127.0.0.1 localhost\n# BEGIN ENTYRE hosts\n10.0.0.1 nas.lan\n"
+	m, path := newTestManager(t, original)
+
+	if entries, err := m.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("entries %+v, %v", entries, err)
+	}
+	if err := m.Add(Entry{IP: "127.0.0.1", Hostname: "app.test"}); err != nil {
+		t.Fatal(err)
+	}
+	want := original + BlockBegin + "\n127.0.0.1\tapp.test\n" + BlockEnd + "\n"
+	if got := readFile(t, path); got != want {
+		t.Fatalf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRemoveWithoutChangeDoesNotWrite(t *testing.T) {
+	original := "127.0.0.1 localhost\n" + BlockBegin + "\n127.0.0.1\tapp.test\n" + BlockEnd + "\n"
+	m, path := newTestManager(t, original)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Remove("other.test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetEntries([]Entry{{IP: "127.0.0.1", Hostname: "app.test"}}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) || !after.ModTime().Equal(before.ModTime()) {
+		t.Fatal("hosts file was rewritten without a change")
+	}
+	if _, err := os.Stat(m.backupPath); !os.IsNotExist(err) {
+		t.Fatalf("backup written without a change: %v", err)
+	}
+}
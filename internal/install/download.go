@@ -0,0 +1,61 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// Download saves url to dest, reporting progress after every chunk. A partial file is removed on failure.
+func Download(ctx context.Context, url, dest string, onProgress ProgressFunc) (err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		out.Close()
+		if err != nil {
+			os.Remove(dest)
+		}
+	}()
+
+	totalBytes := resp.ContentLength
+	var downloadedBytes int64
+	buffer := make([]byte, 32*1024)
+
+	for {
+		n, readErr := resp.Body.Read(buffer)
+		if n > 0 {
+			if _, err := out.Write(buffer[:n]); err != nil {
+				return fmt.Errorf("write error: %v", err)
+			}
+			downloadedBytes += int64(n)
+			if onProgress != nil {
+				onProgress(downloadedBytes, totalBytes)
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("read error: %v", readErr)
+		}
+	}
+}
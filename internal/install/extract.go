@@ -0,0 +1,90 @@
+package install
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// isArchive reports whether name has an extension extract understands
+func isArchive(name string) bool {
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.xz"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// extract unpacks a zip archive natively and tarballs with the system tar,
+// which also handles the xz compression used by MySQL releases
+func extract(ctx context.Context, archive, dest string) error {
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		return extractZip(archive, dest)
+	}
+	if !isArchive(archive) {
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(archive))
+	}
+
+	output, err := exec.CommandContext(ctx, "tar", "-xf", archive, "-C", dest).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to extract %s: %v: %s", filepath.Base(archive), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func extractZip(archive, dest string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		target := filepath.Join(dest, filepath.FromSlash(file.Name))
+		if rel, err := filepath.Rel(dest, target); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("archive entry escapes destination: %s", file.Name)
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		if err := writeZipFile(file, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeZipFile(file *zip.File, target string) error {
+	in, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode().Perm()|0o200)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
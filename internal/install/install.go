@@ -0,0 +1,132 @@
+// Package install downloads and unpacks service releases from the bundled catalogs
+// and registers them in paths.json, without requiring the GUI.
+package install
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// ProgressFunc is called while downloading with the bytes received so far and the total size (-1 if unknown)
+type ProgressFunc func(downloaded, total int64)
+
+// Result describes an installed service version
+type Result struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// Available returns the downloadable versions of a service for the running platform, newest first
+func Available(serviceName string) ([]string, error) {
+	if strings.ToLower(serviceName) != "mysql" {
+		return nil, fmt.Errorf("no download catalog for %s", serviceName)
+	}
+
+	catalog, err := config.LoadMySqlConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, arch := range catalog.Mysql {
+		if arch.Os != config.CurrentConfigOSMySQL() {
+			continue
+		}
+		for _, data := range arch.Data {
+			versions = append(versions, data.Version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return config.CompareVersions(versions[i], versions[j]) > 0
+	})
+	return versions, nil
+}
+
+// Install downloads the newest release of serviceName matching requested, unpacks it into
+// the data directory and records it as an installed version
+func Install(ctx context.Context, serviceName, requested string, onProgress ProgressFunc) (*Result, error) {
+	serviceName = strings.ToLower(serviceName)
+
+	release, ok := config.FindServiceDownload(serviceName, requested)
+	if !ok {
+		return nil, fmt.Errorf("no %s release matching %q is available for this platform", serviceName, requested)
+	}
+
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
+	if err := pathsConfig.LoadConfig(); err != nil {
+		return nil, err
+	}
+	if existing, ok := pathsConfig.GetServiceVersions(serviceName)[release.Version]; ok {
+		if _, err := os.Stat(existing); err == nil {
+			return &Result{Service: serviceName, Version: release.Version, Path: existing}, nil
+		}
+	}
+
+	archive, err := config.DataPath("downloads", path.Base(release.Link))
+	if err != nil {
+		return nil, err
+	}
+	if err := Download(ctx, release.Link, archive, onProgress); err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive)
+
+	target, err := config.DataPath("services", serviceName, release.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", target, err)
+	}
+	if err := unpack(ctx, archive, target, serviceName+"-"+release.Version); err != nil {
+		os.RemoveAll(target)
+		return nil, err
+	}
+
+	if err := pathsConfig.AddServiceVersion(serviceName, release.Version, target, false); err != nil {
+		return nil, err
+	}
+	return &Result{Service: serviceName, Version: release.Version, Path: target}, nil
+}
+
+// unpack extracts archive into target, flattening a single top-level directory.
+// Bundles of nested archives (MySQL's Linux .tar) are unpacked through the one named after prefix.
+func unpack(ctx context.Context, archive, target, prefix string) error {
+	staging := archive + ".extract"
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	if err := extract(ctx, archive, staging); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(staging)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix+"-") && isArchive(entry.Name()) {
+			return unpack(ctx, filepath.Join(staging, entry.Name()), target, prefix)
+		}
+	}
+
+	root := staging
+	if len(entries) == 1 && entries[0].IsDir() {
+		root = filepath.Join(staging, entries[0].Name())
+	}
+	if err := os.Rename(root, target); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", prefix, err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package mail implements a local SMTP mail catcher and message store.
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"strings"
+	"time"
+)
+
+// Summary is the list view of a caught message
+type Summary struct {
+	ID              string    `json:"id"`
+	From            string    `json:"from"`
+	To              []string  `json:"to"`
+	Subject         string    `json:"subject"`
+	ReceivedAt      time.Time `json:"receivedAt"`
+	Size            int       `json:"size"`
+	AttachmentCount int       `json:"attachmentCount"`
+}
+
+// Attachment describes a file attached to a message
+type Attachment struct {
+	Index       int    `json:"index"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	data        []byte
+}
+
+// Message is a fully parsed caught message
+type Message struct {
+	Summary
+	Date        time.Time           `json:"date"`
+	Headers     map[string][]string `json:"headers"`
+	Text        string              `json:"text"`
+	HTML        string              `json:"html"`
+	Attachments []Attachment        `json:"attachments"`
+}
+
+// headerDecoder decodes RFC 2047 encoded words in headers
+var headerDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		// Keep unknown charsets as-is rather than failing the whole message
+		return input, nil
+	},
+}
+
+// ParseMessage parses a raw RFC 5322 message including its MIME parts
+func ParseMessage(raw []byte) (*Message, error) {
+	msg, err := netmail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	parsed := &Message{
+		Summary: Summary{
+			From:    decodeHeader(msg.Header.Get("From")),
+			Subject: decodeHeader(msg.Header.Get("Subject")),
+			Size:    len(raw),
+		},
+		Headers: make(map[string][]string),
+	}
+
+	for key, values := range msg.Header {
+		for _, value := range values {
+			parsed.Headers[key] = append(parsed.Headers[key], decodeHeader(value))
+		}
+	}
+
+	if date, err := msg.Header.Date(); err == nil {
+		parsed.Date = date
+	}
+
+	for _, field := range []string{"To", "Cc"} {
+		if addrs, err := msg.Header.AddressList(field); err == nil {
+			for _, addr := range addrs {
+				parsed.To = append(parsed.To, addr.String())
+			}
+		}
+	}
+
+	if err := parsed.parsePart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body); err != nil {
+		return nil, err
+	}
+
+	parsed.AttachmentCount = len(parsed.Attachments)
+	return parsed, nil
+}
+
+// parsePart walks a MIME part, collecting text bodies and attachments
+func (m *Message) parsePart(contentType, encoding, disposition string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read MIME part: %w", err)
+			}
+
+			err = m.parsePart(
+				part.Header.Get("Content-Type"),
+				part.Header.Get("Content-Transfer-Encoding"),
+				part.Header.Get("Content-Disposition"),
+				part,
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(encoding, body))
+	if err != nil {
+		return fmt.Errorf("failed to decode MIME part: %w", err)
+	}
+
+	dispositionType, dispositionParams, _ := mime.ParseMediaType(disposition)
+	filename := decodeHeader(dispositionParams["filename"])
+	if filename == "" {
+		filename = decodeHeader(params["name"])
+	}
+
+	isAttachment := dispositionType == "attachment" || filename != ""
+	switch {
+	case !isAttachment && mediaType == "text/html" && m.HTML == "":
+		m.HTML = string(data)
+	case !isAttachment && mediaType == "text/plain" && m.Text == "":
+		m.Text = string(data)
+	default:
+		if filename == "" {
+			filename = fmt.Sprintf("attachment-%d", len(m.Attachments)+1)
+		}
+		m.Attachments = append(m.Attachments, Attachment{
+			Index:       len(m.Attachments),
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        len(data),
+			data:        data,
+		})
+	}
+	return nil
+}
+
+// Data returns the decoded attachment content
+func (a *Attachment) Data() []byte {
+	return a.data
+}
+
+// decodeTransfer wraps body in a decoder for the given Content-Transfer-Encoding
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeHeader decodes RFC 2047 encoded words, returning the input on failure
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
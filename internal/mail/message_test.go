@@ -0,0 +1,131 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+const multipartMessage = "From: =?UTF-8?B?SsO8cmdlbg==?= <j@example.com>\r\n" +
+	"To: Ann <ann@example.com>, bob@example.com\r\n" +
+	"Cc: carol@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Caf=C3=A9_order?=\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Caf=C3=A9 total: 3=\r\n" +
+	" items\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Order</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0xLjQ=\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage([]byte(multipartMessage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.From != "Jürgen <j@example.com>" || msg.Subject != "Café order" {
+		t.Fatalf("from %q, subject %q", msg.From, msg.Subject)
+	}
+	if got := strings.Join(msg.To, ", "); got != `"Ann" <ann@example.com>, <bob@example.com>, <carol@example.com>` {
+		t.Fatalf("to %s", got)
+	}
+	if msg.Date.IsZero() || msg.Date.Year() != 2006 {
+		t.Fatalf("date %v", msg.Date)
+	}
+	if msg.Text != "Café total: 3 items" || msg.HTML != "<p>Order</p>" {
+		t.Fatalf("text %q, html %q", msg.Text, msg.HTML)
+	}
+	if len(msg.Attachments) != 1 || msg.AttachmentCount != 1 {
+		t.Fatalf("%d attachments", len(msg.Attachments))
+	}
+	attachment := msg.Attachments[0]
+	if attachment.Filename != "invoice.pdf" || attachment.ContentType != "application/pdf" || string(attachment.Data()) != "%PDF-1.4" {
+		t.Fatalf("attachment %+v %q", attachment, attachment.Data())
+	}
+}
+
+func TestParsePlainMessage(t *testing.T) {
+	msg, err := ParseMessage([]byte("Subject: hi\n\nline one\nline two\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Text != "line one\nline two\n" || msg.HTML != "" || len(msg.Attachments) != 0 {
+		t.Fatalf("message %+v", msg)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := store.Save([]byte(multipartMessage), "bounce@example.com", []string{"x@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unparseable mail is kept with the envelope addresses
+	second, err := store.Save([]byte("not a message"), "bounce@example.com", []string{"x@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.From != "bounce@example.com" || len(second.To) != 1 {
+		t.Fatalf("envelope fallback %+v", second)
+	}
+
+	summaries, err := store.List()
+	if err != nil || len(summaries) != 2 || summaries[0].ID != second.ID {
+		t.Fatalf("list %+v, %v", summaries, err)
+	}
+
+	msg, err := store.Get(first.ID)
+	if err != nil || msg.ID != first.ID || msg.Subject != "Café order" {
+		t.Fatalf("get %+v, %v", msg, err)
+	}
+	if raw, err := store.Raw(first.ID); err != nil || string(raw) != multipartMessage {
+		t.Fatalf("raw differs: %v", err)
+	}
+	if attachment, err := store.Attachment(first.ID, 0); err != nil || attachment.Filename != "invoice.pdf" {
+		t.Fatalf("attachment %+v, %v", attachment, err)
+	}
+	if _, err := store.Attachment(first.ID, 1); err == nil {
+		t.Fatal("missing attachment returned")
+	}
+
+	for _, id := range []string{"../secret", "", "ABC"} {
+		if _, err := store.Get(id); err == nil {
+			t.Fatalf("Get(%q) succeeded", id)
+		}
+	}
+
+	if err := store.Delete(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(first.ID); err == nil {
+		t.Fatal("deleted a message twice")
+	}
+	if err := store.Purge(); err != nil {
+		t.Fatal(err)
+	}
+	if summaries, _ := store.List(); len(summaries) != 0 {
+		t.Fatalf("%d messages after purge", len(summaries))
+	}
+}
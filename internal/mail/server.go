@@ -0,0 +1,343 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxMessageSize = 25 << 20
+	sessionTimeout = 5 * time.Minute
+	serverHostname = "enty.local"
+)
+
+// ReceiveCallback is called after a message has been stored
+type ReceiveCallback func(summary Summary)
+
+// Server is an SMTP server that accepts every message and stores it locally
+type Server struct {
+	addr      string
+	store     *Store
+	onReceive ReceiveCallback
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+}
+
+// NewServer creates a new SMTP server listening on addr and storing into store
+func NewServer(addr string, store *Store, onReceive ReceiveCallback) *Server {
+	return &Server{
+		addr:      addr,
+		store:     store,
+		onReceive: onReceive,
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Start begins accepting SMTP connections
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener != nil {
+		return fmt.Errorf("mail server is already running")
+	}
+
+	if err := checkLoopback(s.addr); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	s.listener = listener
+
+	s.wg.Add(1)
+	go s.serve(listener)
+	return nil
+}
+
+// Stop closes the listener and all open sessions
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	if s.listener == nil {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.listener.Close()
+	s.listener = nil
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// IsRunning returns whether the server is accepting connections
+func (s *Server) IsRunning() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.listener != nil
+}
+
+// Addr returns the address the server is bound to
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// checkLoopback rejects listen addresses reachable from other machines. The catcher accepts any
+// credentials and stores every message, so on a LAN address anyone could fill the disk.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid mail listen address %q: %w", addr, err)
+	}
+	if strings.EqualFold(host, "localhost") {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("mail server must listen on a loopback address, not %q", host)
+}
+
+func (s *Server) serve(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+
+		s.mutex.Lock()
+		s.conns[conn] = struct{}{}
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mutex.Lock()
+				delete(s.conns, conn)
+				s.mutex.Unlock()
+				conn.Close()
+			}()
+			s.handleSession(conn)
+		}()
+	}
+}
+
+// smtpSession holds the envelope state of a single SMTP connection
+type smtpSession struct {
+	text *textproto.Conn
+	from string
+	to   []string
+	helo bool
+	mail bool
+}
+
+// handleSession runs the SMTP command loop for one connection
+func (s *Server) handleSession(conn net.Conn) {
+	session := &smtpSession{text: textproto.NewConn(conn)}
+	session.reply(220, serverHostname+" Enty mail catcher ready")
+
+	for {
+		conn.SetDeadline(time.Now().Add(sessionTimeout))
+
+		line, err := session.text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			session.helo = true
+			session.reset()
+			session.reply(250, serverHostname)
+		case "EHLO":
+			session.helo = true
+			session.reset()
+			session.replyLines(250,
+				serverHostname,
+				fmt.Sprintf("SIZE %d", maxMessageSize),
+				"8BITMIME",
+				"AUTH PLAIN LOGIN",
+			)
+		case "AUTH":
+			// Every credential is accepted; the catcher only needs the client to proceed
+			if !session.authenticate(arg) {
+				return
+			}
+		case "MAIL":
+			if !session.helo {
+				session.reply(503, "Send HELO/EHLO first")
+				continue
+			}
+			address, ok := parsePath(arg, "FROM:")
+			if !ok {
+				session.reply(501, "Syntax: MAIL FROM:<address>")
+				continue
+			}
+			session.reset()
+			session.mail = true
+			session.from = address
+			session.reply(250, "OK")
+		case "RCPT":
+			if !session.mail {
+				session.reply(503, "Need MAIL command")
+				continue
+			}
+			address, ok := parsePath(arg, "TO:")
+			if !ok || address == "" {
+				session.reply(501, "Syntax: RCPT TO:<address>")
+				continue
+			}
+			session.to = append(session.to, address)
+			session.reply(250, "OK")
+		case "DATA":
+			if len(session.to) == 0 {
+				session.reply(503, "Need RCPT command")
+				continue
+			}
+			session.reply(354, "End data with <CR><LF>.<CR><LF>")
+			if err := s.receiveData(session); err != nil {
+				if errors.Is(err, errMessageTooLarge) {
+					session.reply(552, "Message exceeds maximum size")
+					session.reset()
+					continue
+				}
+				log.Printf("Mail catcher failed to store message: %v", err)
+				session.reply(451, "Failed to store message")
+				session.reset()
+				continue
+			}
+			session.reset()
+			session.reply(250, "OK: message queued")
+		case "RSET":
+			session.reset()
+			session.reply(250, "OK")
+		case "NOOP":
+			session.reply(250, "OK")
+		case "VRFY":
+			session.reply(252, "Cannot VRFY user, but will accept message")
+		case "QUIT":
+			session.reply(221, "Bye")
+			return
+		default:
+			session.reply(502, "Command not implemented")
+		}
+	}
+}
+
+var errMessageTooLarge = errors.New("message too large")
+
+// receiveData reads the dot-terminated message body and stores it
+func (s *Server) receiveData(session *smtpSession) error {
+	reader := session.text.DotReader()
+	raw, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxMessageSize {
+		io.Copy(io.Discard, reader)
+		return errMessageTooLarge
+	}
+
+	summary, err := s.store.Save(raw, session.from, session.to)
+	if err != nil {
+		return err
+	}
+
+	if s.onReceive != nil {
+		s.onReceive(*summary)
+	}
+	return nil
+}
+
+// authenticate completes an AUTH exchange, accepting any credentials
+func (session *smtpSession) authenticate(arg string) bool {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		if initial == "" {
+			session.reply(334, "")
+			if _, err := session.text.ReadLine(); err != nil {
+				return false
+			}
+		}
+	case "LOGIN":
+		prompts := []string{"VXNlcm5hbWU6", "UGFzc3dvcmQ6"}
+		if initial != "" {
+			prompts = prompts[1:]
+		}
+		for _, prompt := range prompts {
+			session.reply(334, prompt)
+			if _, err := session.text.ReadLine(); err != nil {
+				return false
+			}
+		}
+	default:
+		session.reply(504, "Unrecognized authentication type")
+		return true
+	}
+
+	session.reply(235, "Authentication successful")
+	return true
+}
+
+func (session *smtpSession) reset() {
+	session.mail = false
+	session.from = ""
+	session.to = nil
+}
+
+func (session *smtpSession) reply(code int, message string) {
+	session.text.PrintfLine("%d %s", code, message)
+}
+
+func (session *smtpSession) replyLines(code int, lines ...string) {
+	for i, line := range lines {
+		separator := "-"
+		if i == len(lines)-1 {
+			separator = " "
+		}
+		fmt.Fprintf(session.text.W, "%d%s%s\r\n", code, separator, line)
+	}
+	session.text.W.Flush()
+}
+
+// parsePath extracts the address from "FROM:<addr> PARAMS" style arguments
+func parsePath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	path := strings.TrimSpace(arg[len(prefix):])
+	if fields := strings.Fields(path); len(fields) > 0 {
+		path = fields[0]
+	}
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return strings.Trim(path, "<>"), path != ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, "<"), ">"), true
+}
@@ -0,0 +1,63 @@
+package mail
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"testing"
+)
+
+func TestSMTPRoundTrip(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan Summary, 1)
+	server := NewServer("127.0.0.1:0", store, func(summary Summary) { received <- summary })
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	body := "From: app@shop.test\r\nTo: customer@example.com\r\nSubject: Welcome\r\n\r\nHello\r\n.leading dot\r\n"
+	auth := smtp.PlainAuth("", "user", "password", "127.0.0.1")
+	if err := smtp.SendMail(server.Addr(), auth, "app@shop.test", []string{"customer@example.com", "bcc@example.com"}, []byte(body)); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := <-received
+	if summary.Subject != "Welcome" || summary.From != "app@shop.test" {
+		t.Fatalf("summary %+v", summary)
+	}
+	raw, err := store.Raw(summary.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The DATA reader undoes dot-stuffing and normalizes line endings
+	if want := "From: app@shop.test\nTo: customer@example.com\nSubject: Welcome\n\nHello\n.leading dot\n"; string(raw) != want {
+		t.Fatalf("raw %q, want %q", raw, want)
+	}
+}
+
+func TestRejectsNonLoopbackAddress(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []string{"0.0.0.0:0", "192.168.1.10:1025", "[::]:0", "mail.example.com:25", "1025"} {
+		if err := NewServer(addr, store, nil).Start(); err == nil {
+			t.Fatalf("%s: server started on a non-loopback address", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:0", "localhost:0"} {
+		server := NewServer(addr, store, nil)
+		if err := server.Start(); err != nil {
+			var opErr *net.OpError
+			if errors.As(err, &opErr) {
+				t.Skipf("%s: %v", addr, err)
+			}
+			t.Fatalf("%s: %v", addr, err)
+		}
+		server.Stop()
+	}
+}
@@ -0,0 +1,218 @@
+package mail
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps caught messages on disk as raw .eml files with a JSON summary alongside
+type Store struct {
+	dir   string
+	mutex sync.RWMutex
+}
+
+// NewStore creates a message store in dir
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail store: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save stores a raw message with its SMTP envelope and returns its summary
+func (s *Store) Save(raw []byte, envelopeFrom string, envelopeTo []string) (*Summary, error) {
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		// Keep unparseable mail so it can still be inspected as raw source
+		msg = &Message{Summary: Summary{Size: len(raw)}}
+	}
+
+	summary := msg.Summary
+	summary.ID = newMessageID()
+	summary.ReceivedAt = time.Now()
+	if summary.From == "" {
+		summary.From = envelopeFrom
+	}
+	if len(summary.To) == 0 {
+		summary.To = envelopeTo
+	}
+
+	meta, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message summary: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.WriteFile(s.rawPath(summary.ID), raw, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to store message: %w", err)
+	}
+	if err := os.WriteFile(s.metaPath(summary.ID), meta, 0o600); err != nil {
+		os.Remove(s.rawPath(summary.ID))
+		return nil, fmt.Errorf("failed to store message summary: %w", err)
+	}
+	return &summary, nil
+}
+
+// List returns summaries of all stored messages, newest first
+func (s *Store) List() ([]Summary, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var summary Summary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].ReceivedAt.After(summaries[j].ReceivedAt)
+	})
+	return summaries, nil
+}
+
+// Get returns a fully parsed message
+func (s *Store) Get(id string) (*Message, error) {
+	raw, summary, err := s.load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := ParseMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+	msg.Summary = *summary
+	return msg, nil
+}
+
+// Raw returns the original message source
+func (s *Store) Raw(id string) ([]byte, error) {
+	raw, _, err := s.load(id)
+	return raw, err
+}
+
+// Attachment returns an attachment of a message by index
+func (s *Store) Attachment(id string, index int) (*Attachment, error) {
+	msg, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(msg.Attachments) {
+		return nil, fmt.Errorf("attachment %d not found in message %s", index, id)
+	}
+	return &msg.Attachments[index], nil
+}
+
+// Delete removes a single message
+func (s *Store) Delete(id string) error {
+	if !validMessageID(id) {
+		return fmt.Errorf("message %s not found", id)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(s.metaPath(id)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("message %s not found", id)
+		}
+		return err
+	}
+	os.Remove(s.rawPath(id))
+	return nil
+}
+
+// Purge removes all stored messages
+func (s *Store) Purge() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".eml") {
+			if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+				return fmt.Errorf("failed to purge messages: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// load reads the raw message and its summary
+func (s *Store) load(id string) ([]byte, *Summary, error) {
+	if !validMessageID(id) {
+		return nil, nil, fmt.Errorf("message %s not found", id)
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	meta, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("message %s not found", id)
+	}
+	var summary Summary
+	if err := json.Unmarshal(meta, &summary); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse message summary: %w", err)
+	}
+
+	raw, err := os.ReadFile(s.rawPath(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read message %s: %w", id, err)
+	}
+	return raw, &summary, nil
+}
+
+func (s *Store) rawPath(id string) string {
+	return filepath.Join(s.dir, id+".eml")
+}
+
+func (s *Store) metaPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// newMessageID returns a sortable unique message ID
+func newMessageID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), hex.EncodeToString(suffix))
+}
+
+// validMessageID guards against path traversal through message IDs
+func validMessageID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package proctree
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc/<pid>/stat; it is 100 on all supported architectures
+const clockTicks = 100
+
+// listProcesses reads every process from /proc
+func listProcesses() ([]*Process, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+	uptime, err := readUptime()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pageSize := uint64(os.Getpagesize())
+	alive := make(map[int]bool)
+	var processes []*Process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit while they are being read
+		process, cpu, startTicks, err := readStat(pid, pageSize)
+		if err != nil {
+			continue
+		}
+		lifetime := uptime - time.Duration(startTicks)*time.Second/clockTicks
+		process.CPUPercent = cpuPercent(pid, cpu, lifetime, now)
+		process.Command = readCmdline(pid, process.Name)
+		alive[pid] = true
+		processes = append(processes, process)
+	}
+	pruneSamples(alive)
+	return processes, nil
+}
+
+// readStat parses /proc/<pid>/stat, returning the process, its CPU time and its start time in clock ticks
+func readStat(pid int, pageSize uint64) (*Process, time.Duration, uint64, error) {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	// The name is in parentheses and may itself contain spaces and parentheses
+	open := bytes.IndexByte(data, '(')
+	closing := bytes.LastIndexByte(data, ')')
+	if open < 0 || closing < open {
+		return nil, 0, 0, fmt.Errorf("malformed stat for %d", pid)
+	}
+	fields := strings.Fields(string(data[closing+1:]))
+	if len(fields) < 22 {
+		return nil, 0, 0, fmt.Errorf("malformed stat for %d", pid)
+	}
+
+	field := func(i int) uint64 {
+		value, _ := strconv.ParseUint(fields[i], 10, 64)
+		return value
+	}
+	ticks := field(11) + field(12) // utime + stime
+
+	return &Process{
+		PID:         pid,
+		PPID:        int(field(1)),
+		PGID:        int(field(2)),
+		Name:        string(data[open+1 : closing]),
+		State:       fields[0],
+		MemoryBytes: field(21) * pageSize,
+	}, time.Duration(ticks) * time.Second / clockTicks, field(19), nil
+}
+
+// readCmdline returns the command line of a process, or its name for kernel threads
+func readCmdline(pid int, name string) string {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return name
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+}
+
+func readUptime() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/uptime")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read uptime: %w", err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("malformed /proc/uptime")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed /proc/uptime: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
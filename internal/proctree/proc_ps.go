@@ -0,0 +1,48 @@
+//go:build !linux && !windows
+
+package proctree
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses reads every process from ps(1), as these systems have no /proc
+func listProcesses() ([]*Process, error) {
+	output, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,rss=,%cpu=,state=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var processes []*Process
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 7 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		ppid, _ := strconv.Atoi(fields[1])
+		pgid, _ := strconv.Atoi(fields[2])
+		rss, _ := strconv.ParseUint(fields[3], 10, 64)
+		cpu, _ := strconv.ParseFloat(fields[4], 64)
+		command := strings.Join(fields[6:], " ")
+
+		processes = append(processes, &Process{
+			PID:         pid,
+			PPID:        ppid,
+			PGID:        pgid,
+			Name:        filepath.Base(fields[6]),
+			Command:     command,
+			State:       fields[5],
+			CPUPercent:  cpu,
+			MemoryBytes: rss * 1024,
+		})
+	}
+	return processes, nil
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package proctree
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// listProcesses reads every process from a Toolhelp snapshot. Windows has no process groups,
+// so each process is its own group, and CPU and memory usage are not reported.
+func listProcesses() ([]*Process, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	var processes []*Process
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		name := windows.UTF16ToString(entry.ExeFile[:])
+		processes = append(processes, &Process{
+			PID:     int(entry.ProcessID),
+			PPID:    int(entry.ParentProcessID),
+			PGID:    int(entry.ProcessID),
+			Name:    name,
+			Command: name,
+		})
+	}
+	return processes, nil
+}
@@ -0,0 +1,145 @@
+// Package proctree inspects the processes running beneath a terminal session and sends them signals.
+package proctree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Process is one process in a tree
+type Process struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	PGID    int    `json:"pgid"`
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	State   string `json:"state,omitempty"`
+	// CPUPercent is the CPU usage since the previous inspection, or over the process lifetime
+	// on the first one; 100 means one full core
+	CPUPercent  float64    `json:"cpuPercent"`
+	MemoryBytes uint64     `json:"memoryBytes"`
+	Children    []*Process `json:"children,omitempty"`
+}
+
+// Tree returns the process rootPID with all of its descendants
+func Tree(rootPID int) (*Process, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	byPID := make(map[int]*Process, len(processes))
+	for _, process := range processes {
+		byPID[process.PID] = process
+	}
+	root, ok := byPID[rootPID]
+	if !ok {
+		return nil, fmt.Errorf("process %d not found", rootPID)
+	}
+
+	for _, process := range processes {
+		if process.PID == rootPID {
+			continue
+		}
+		if parent, ok := byPID[process.PPID]; ok {
+			parent.Children = append(parent.Children, process)
+		}
+	}
+	for _, process := range processes {
+		sort.Slice(process.Children, func(i, j int) bool { return process.Children[i].PID < process.Children[j].PID })
+	}
+	return root, nil
+}
+
+// Contains reports whether pid is the root of the tree or one of its descendants
+func (p *Process) Contains(pid int) bool {
+	if p.PID == pid {
+		return true
+	}
+	for _, child := range p.Children {
+		if child.Contains(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsGroup reports whether any process of the tree belongs to process group pgid
+func (p *Process) ContainsGroup(pgid int) bool {
+	if p.PGID == pgid {
+		return true
+	}
+	for _, child := range p.Children {
+		if child.ContainsGroup(pgid) {
+			return true
+		}
+	}
+	return false
+}
+
+// Signals that can be sent to processes
+const (
+	SIGINT  = "SIGINT"
+	SIGTERM = "SIGTERM"
+	SIGKILL = "SIGKILL"
+)
+
+// ParseSignal normalizes a signal name such as "int", "TERM" or "SIGKILL"
+func ParseSignal(name string) (string, error) {
+	signal := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(signal, "SIG") {
+		signal = "SIG" + signal
+	}
+	switch signal {
+	case SIGINT, SIGTERM, SIGKILL:
+		return signal, nil
+	default:
+		return "", fmt.Errorf("unsupported signal %q (use SIGINT, SIGTERM or SIGKILL)", name)
+	}
+}
+
+// cpuSample is the CPU time a process had used at a point in time
+type cpuSample struct {
+	cpu time.Duration
+	at  time.Time
+}
+
+// maxSampleAge is how old a previous sample may be to compute current CPU usage from it
+const maxSampleAge = 30 * time.Second
+
+var (
+	samples     = make(map[int]cpuSample)
+	samplesLock sync.Mutex
+)
+
+// cpuPercent returns the CPU usage of pid since its previous sample, falling back to the lifetime
+// average, and stores the new sample
+func cpuPercent(pid int, cpu, lifetime time.Duration, now time.Time) float64 {
+	samplesLock.Lock()
+	defer samplesLock.Unlock()
+
+	previous, ok := samples[pid]
+	samples[pid] = cpuSample{cpu: cpu, at: now}
+
+	if ok && cpu >= previous.cpu && now.Sub(previous.at) > 0 && now.Sub(previous.at) < maxSampleAge {
+		return float64(cpu-previous.cpu) / float64(now.Sub(previous.at)) * 100
+	}
+	if lifetime <= 0 {
+		return 0
+	}
+	return float64(cpu) / float64(lifetime) * 100
+}
+
+// pruneSamples forgets processes that no longer exist
+func pruneSamples(alive map[int]bool) {
+	samplesLock.Lock()
+	defer samplesLock.Unlock()
+	for pid := range samples {
+		if !alive[pid] {
+			delete(samples, pid)
+		}
+	}
+}
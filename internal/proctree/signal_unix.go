@@ -0,0 +1,21 @@
+//go:build !windows
+
+package proctree
+
+import "syscall"
+
+var signals = map[string]syscall.Signal{
+	SIGINT:  syscall.SIGINT,
+	SIGTERM: syscall.SIGTERM,
+	SIGKILL: syscall.SIGKILL,
+}
+
+// Signal sends a signal, as returned by ParseSignal, to one process
+func Signal(pid int, signal string) error {
+	return syscall.Kill(pid, signals[signal])
+}
+
+// SignalGroup sends a signal, as returned by ParseSignal, to every process in a process group
+func SignalGroup(pgid int, signal string) error {
+	return syscall.Kill(-pgid, signals[signal])
+}
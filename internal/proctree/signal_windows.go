@@ -0,0 +1,26 @@
+//go:build windows
+
+package proctree
+
+import (
+	"fmt"
+	"os"
+)
+
+// Signal stops a process. Windows cannot deliver SIGINT or SIGTERM to other processes, so only
+// SIGKILL is supported; interrupt console programs by writing Ctrl+C to the terminal instead.
+func Signal(pid int, signal string) error {
+	if signal != SIGKILL {
+		return fmt.Errorf("%s is not supported on Windows; use SIGKILL", signal)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
+
+// SignalGroup is Signal, as Windows has no process groups
+func SignalGroup(pgid int, signal string) error {
+	return Signal(pgid, signal)
+}
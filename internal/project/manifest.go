@@ -0,0 +1,141 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// ManifestFileName is the per-project manifest discovered in project directories
+const ManifestFileName = ".enty.json"
+
+// Manifest declares the services, environment and ports a project needs, similar to .tool-versions
+type Manifest struct {
+	// Services maps service names to required versions, e.g. {"php": "8.2"}
+	Services map[string]string `json:"services"`
+	Env      map[string]string `json:"env,omitempty"`
+	Ports    map[string]int    `json:"ports,omitempty"`
+}
+
+// ResolvedService is a manifest requirement satisfied by an installed version
+type ResolvedService struct {
+	Service   string `json:"service"`
+	Requested string `json:"requested"`
+	Version   string `json:"version"`
+	Path      string `json:"path"`
+}
+
+// MissingService is a manifest requirement without a matching installed version
+type MissingService struct {
+	Service   string `json:"service"`
+	Requested string `json:"requested"`
+	// Download is the matching release that can be installed, if the service has a download catalog
+	Download *config.ConfigDataMySQL `json:"download,omitempty"`
+}
+
+// ManifestStatus reports how a manifest compares against the installed versions
+type ManifestStatus struct {
+	Path     string            `json:"path"`
+	Resolved []ResolvedService `json:"resolved"`
+	Missing  []MissingService  `json:"missing"`
+}
+
+// LoadManifest reads the manifest in dir
+func LoadManifest(dir string) (*Manifest, error) {
+	path := filepath.Join(dir, ManifestFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	services := make(map[string]string, len(manifest.Services))
+	for name, version := range manifest.Services {
+		services[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(version)
+	}
+	manifest.Services = services
+	return &manifest, nil
+}
+
+// FindManifest walks up from dir and returns the directory containing the nearest manifest
+func FindManifest(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if stat, err := os.Stat(filepath.Join(dir, ManifestFileName)); err == nil && !stat.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Validate checks every required service against the installed-versions registry
+func (m *Manifest) Validate(pathsConfig *config.PathsConfigManager) ManifestStatus {
+	status := ManifestStatus{
+		Resolved: []ResolvedService{},
+		Missing:  []MissingService{},
+	}
+
+	names := make([]string, 0, len(m.Services))
+	for name := range m.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		requested := m.Services[name]
+		if version, path, ok := pathsConfig.ResolveServiceVersion(name, requested); ok {
+			status.Resolved = append(status.Resolved, ResolvedService{
+				Service:   name,
+				Requested: requested,
+				Version:   version,
+				Path:      path,
+			})
+			continue
+		}
+
+		missing := MissingService{Service: name, Requested: requested}
+		if download, ok := config.FindServiceDownload(name, requested); ok {
+			missing.Download = download
+		}
+		status.Missing = append(status.Missing, missing)
+	}
+	return status
+}
+
+// ApplyManifest replaces the pins taken from the previous manifest with the manifest's service
+// requirements. Pins the user set or changed by hand are kept, unless the manifest now sets them.
+func (p *Project) ApplyManifest(m *Manifest) {
+	if p.Services == nil {
+		p.Services = make(map[string]string)
+	}
+	for name, version := range p.ManifestServices {
+		if p.Services[name] == version {
+			delete(p.Services, name)
+		}
+	}
+
+	p.ManifestServices = make(map[string]string, len(m.Services))
+	for name, version := range m.Services {
+		if name != "" && version != "" {
+			p.Services[name] = version
+			p.ManifestServices[name] = version
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package project
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestApplyManifestReplacesManifestPins(t *testing.T) {
+	p := &Project{Services: map[string]string{"node": "20"}}
+
+	p.ApplyManifest(&Manifest{Services: map[string]string{"php": "8.2", "mysql": "8.0"}})
+	if want := map[string]string{"node": "20", "php": "8.2", "mysql": "8.0"}; !maps.Equal(p.Services, want) {
+		t.Fatalf("first load: %v, want %v", p.Services, want)
+	}
+
+	// The user changes the mysql pin by hand; removing mysql from the manifest keeps that pin
+	p.Services["mysql"] = "8.4"
+	p.ApplyManifest(&Manifest{Services: map[string]string{"php": "8.3"}})
+	if want := map[string]string{"node": "20", "php": "8.3", "mysql": "8.4"}; !maps.Equal(p.Services, want) {
+		t.Fatalf("reload: %v, want %v", p.Services, want)
+	}
+
+	// An empty or deleted manifest drops every pin it contributed
+	p.ApplyManifest(&Manifest{})
+	if want := map[string]string{"node": "20", "mysql": "8.4"}; !maps.Equal(p.Services, want) {
+		t.Fatalf("empty manifest: %v, want %v", p.Services, want)
+	}
+	if len(p.ManifestServices) != 0 {
+		t.Fatalf("manifest pins still recorded: %v", p.ManifestServices)
+	}
+}
@@ -0,0 +1,316 @@
+// Package project keeps the registry of local projects and their pinned service versions.
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Project is a local site with its own hostname and pinned service versions
+type Project struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Root     string `json:"root"`
+	Hostname string `json:"hostname"`
+	// Services pins service names to versions, e.g. {"php": "8.2", "mysql": "8.0.41"}
+	Services map[string]string `json:"services"`
+	// ManifestServices are the pins last taken from the project's manifest, so they can be
+	// replaced when the manifest changes
+	ManifestServices map[string]string `json:"manifestServices,omitempty"`
+}
+
+// Registry stores projects in a JSON file
+type Registry struct {
+	path     string
+	projects map[string]*Project
+	mutex    sync.RWMutex
+}
+
+// NewRegistry loads the registry stored at path, starting empty if the file does not exist
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{
+		path:     path,
+		projects: make(map[string]*Project),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+
+	var projects []*Project
+	if err := json.Unmarshal(data, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse project registry: %w", err)
+	}
+	for _, p := range projects {
+		r.projects[p.ID] = p
+	}
+	return r, nil
+}
+
+// List returns all projects sorted by name
+func (r *Registry) List() []Project {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	projects := make([]Project, 0, len(r.projects))
+	for _, p := range r.projects {
+		projects = append(projects, p.clone())
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+	})
+	return projects
+}
+
+// Get returns a project by ID
+func (r *Registry) Get(id string) (*Project, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, exists := r.projects[id]
+	if !exists {
+		return nil, fmt.Errorf("project %s not found", id)
+	}
+	clone := p.clone()
+	return &clone, nil
+}
+
+// FindByDir returns the project whose root contains dir, preferring the deepest match
+func (r *Registry) FindByDir(dir string) (*Project, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var found *Project
+	for _, p := range r.projects {
+		rel, err := filepath.Rel(p.Root, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if found == nil || len(p.Root) > len(found.Root) {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, false
+	}
+	clone := found.clone()
+	return &clone, true
+}
+
+// Add registers a new project, deriving its ID from the name
+func (r *Registry) Add(p Project) (*Project, error) {
+	if err := normalize(&p); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.checkConflicts(p, ""); err != nil {
+		return nil, err
+	}
+
+	p.ID = r.uniqueID(slugify(p.Name))
+	r.projects[p.ID] = &p
+	if err := r.save(); err != nil {
+		delete(r.projects, p.ID)
+		return nil, err
+	}
+
+	clone := p.clone()
+	return &clone, nil
+}
+
+// CheckAvailable reports whether a project with p's root and hostname could be added.
+// Unlike Add, the root does not have to exist yet.
+func (r *Registry) CheckAvailable(p Project) error {
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return fmt.Errorf("invalid project root: %w", err)
+	}
+	p.Root = root
+	p.Hostname = strings.ToLower(strings.TrimSpace(p.Hostname))
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.checkConflicts(p, "")
+}
+
+// Update replaces an existing project's settings
+func (r *Registry) Update(p Project) error {
+	if err := normalize(&p); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.projects[p.ID]
+	if !exists {
+		return fmt.Errorf("project %s not found", p.ID)
+	}
+	if p.ManifestServices == nil {
+		// Callers that do not know about manifest pins keep the recorded set
+		p.ManifestServices = previous.clone().ManifestServices
+	}
+	if err := r.checkConflicts(p, p.ID); err != nil {
+		return err
+	}
+
+	r.projects[p.ID] = &p
+	if err := r.save(); err != nil {
+		r.projects[p.ID] = previous
+		return err
+	}
+	return nil
+}
+
+// Remove unregisters a project; its files are left untouched
+func (r *Registry) Remove(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, exists := r.projects[id]
+	if !exists {
+		return fmt.Errorf("project %s not found", id)
+	}
+
+	delete(r.projects, id)
+	if err := r.save(); err != nil {
+		r.projects[id] = previous
+		return err
+	}
+	return nil
+}
+
+// checkConflicts ensures no other project uses the same root or hostname (assumes lock is held)
+func (r *Registry) checkConflicts(p Project, skipID string) error {
+	for id, existing := range r.projects {
+		if id == skipID {
+			continue
+		}
+		if existing.Root == p.Root {
+			return fmt.Errorf("project %s already uses %s", existing.Name, p.Root)
+		}
+		if p.Hostname != "" && existing.Hostname == p.Hostname {
+			return fmt.Errorf("hostname %s is already used by project %s", p.Hostname, existing.Name)
+		}
+	}
+	return nil
+}
+
+// save writes the registry to disk (assumes lock is held)
+func (r *Registry) save() error {
+	projects := make([]*Project, 0, len(r.projects))
+	for _, p := range r.projects {
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID < projects[j].ID })
+
+	data, err := json.MarshalIndent(projects, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode project registry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create project registry directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write project registry: %w", err)
+	}
+	return nil
+}
+
+// uniqueID returns base, or base with a numeric suffix if it is taken (assumes lock is held)
+func (r *Registry) uniqueID(base string) string {
+	id := base
+	for i := 2; ; i++ {
+		if _, exists := r.projects[id]; !exists {
+			return id
+		}
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+func (p Project) clone() Project {
+	services := make(map[string]string, len(p.Services))
+	for name, version := range p.Services {
+		services[name] = version
+	}
+	p.Services = services
+	if p.ManifestServices != nil {
+		manifestServices := make(map[string]string, len(p.ManifestServices))
+		for name, version := range p.ManifestServices {
+			manifestServices[name] = version
+		}
+		p.ManifestServices = manifestServices
+	}
+	return p
+}
+
+// normalize validates a project and cleans up its fields
+func normalize(p *Project) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Root == "" {
+		return fmt.Errorf("project root directory is required")
+	}
+
+	root, err := filepath.Abs(p.Root)
+	if err != nil {
+		return fmt.Errorf("invalid project root: %w", err)
+	}
+	if stat, err := os.Stat(root); err != nil || !stat.IsDir() {
+		return fmt.Errorf("project root does not exist: %s", root)
+	}
+	p.Root = root
+
+	if p.Name == "" {
+		p.Name = filepath.Base(root)
+	}
+	p.Hostname = strings.ToLower(strings.TrimSpace(p.Hostname))
+
+	services := make(map[string]string, len(p.Services))
+	for name, version := range p.Services {
+		name = strings.ToLower(strings.TrimSpace(name))
+		version = strings.TrimSpace(version)
+		if name != "" && version != "" {
+			services[name] = version
+		}
+	}
+	p.Services = services
+	return nil
+}
+
+// slugify turns a project name into a lowercase, dash-separated ID
+func slugify(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+			dash = false
+		} else if !dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := strings.TrimSuffix(sb.String(), "-")
+	if slug == "" {
+		return "project"
+	}
+	return slug
+}
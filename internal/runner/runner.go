@@ -0,0 +1,241 @@
+// Package runner executes one-off, non-interactive commands in the same isolated environment
+// as terminal sessions, streaming stdout and stderr separately and reporting the exit status.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	gosruntime "runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+const (
+	// maxCapturedOutput is the number of bytes of each stream kept in the Result
+	maxCapturedOutput = 1 << 20
+	// waitDelay is how long output is still read after the process was stopped
+	waitDelay = 2 * time.Second
+)
+
+// Options describes a command to run
+type Options struct {
+	// Args is the command and its arguments; the command is looked up on the isolated PATH
+	Args []string
+	Dir  string
+	// Service limits PATH to one service; empty places every configured service on PATH
+	Service string
+	// ServicePins selects specific installed versions, for example a project's pins
+	ServicePins map[string]string
+	Env         map[string]string
+	// Timeout stops the command after the given duration; 0 means no timeout
+	Timeout time.Duration
+	// OnStdout and OnStderr receive output as it is produced
+	OnStdout func(data string)
+	OnStderr func(data string)
+}
+
+// Result is the outcome of a finished command
+type Result struct {
+	ExitCode int    `json:"exitCode"`
+	Signal   string `json:"signal,omitempty"`
+	// Stdout and Stderr hold the first megabyte of each stream
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	DurationMs int64  `json:"durationMs"`
+	TimedOut   bool   `json:"timedOut"`
+	Canceled   bool   `json:"canceled"`
+}
+
+// Run executes the command and waits for it to finish. Canceling ctx stops the command and its
+// children. A non-zero exit code is reported in the Result, not as an error.
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	if len(opts.Args) == 0 || opts.Args[0] == "" {
+		return nil, errors.New("no command given")
+	}
+
+	var services []string
+	if opts.Service != "" {
+		services = []string{opts.Service}
+	}
+	env := utils.BuildIsolatedEnv(utils.IsolatedEnvOptions{
+		ServicePins: opts.ServicePins,
+		Services:    services,
+		Env:         opts.Env,
+	})
+
+	path, err := lookPath(opts.Args[0], envValue(env, "PATH"))
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	stdout := &stream{onData: opts.OnStdout}
+	stderr := &stream{onData: opts.OnStderr}
+
+	cmd := exec.CommandContext(runCtx, path, opts.Args[1:]...)
+	cmd.Dir = opts.Dir
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+	setProcessGroup(cmd)
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", opts.Args[0], err)
+	}
+	err = cmd.Wait()
+	stdout.flush()
+	stderr.flush()
+
+	result := &Result{
+		ExitCode:   -1,
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		DurationMs: time.Since(start).Milliseconds(),
+		TimedOut:   errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil,
+		Canceled:   ctx.Err() != nil,
+	}
+	if state := cmd.ProcessState; state != nil {
+		result.ExitCode = state.ExitCode()
+		if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			result.Signal = status.Signal().String()
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// stream forwards output to a callback and keeps the beginning of it. Output is passed on in
+// whole UTF-8 characters, so a character split across two writes is not delivered as two
+// invalid halves.
+type stream struct {
+	onData func(data string)
+	mutex  sync.Mutex
+	buf    strings.Builder
+	full   bool
+	// pending holds the start of a character whose remaining bytes have not arrived yet
+	pending []byte
+}
+
+func (s *stream) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	data := append(s.pending, p...)
+	complete := len(data) - incompleteSuffix(data)
+	s.pending = append([]byte(nil), data[complete:]...)
+	data = data[:complete]
+	s.capture(data)
+	s.mutex.Unlock()
+
+	if s.onData != nil && len(data) > 0 {
+		s.onData(string(data))
+	}
+	return len(p), nil
+}
+
+// flush passes on bytes held back at the end of the output, which were never completed
+func (s *stream) flush() {
+	s.mutex.Lock()
+	data := s.pending
+	s.pending = nil
+	s.capture(data)
+	s.mutex.Unlock()
+
+	if s.onData != nil && len(data) > 0 {
+		s.onData(string(data))
+	}
+}
+
+// capture keeps data up to maxCapturedOutput, cutting at a character boundary (assumes lock is held)
+func (s *stream) capture(data []byte) {
+	if s.full {
+		return
+	}
+	if remaining := maxCapturedOutput - s.buf.Len(); len(data) > remaining {
+		for remaining > 0 && !utf8.RuneStart(data[remaining]) {
+			remaining--
+		}
+		data = data[:remaining]
+		s.full = true
+	}
+	s.buf.Write(data)
+}
+
+// incompleteSuffix returns the length of a multi-byte character cut off at the end of data
+func incompleteSuffix(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return 0
+			}
+			return len(data) - i
+		}
+	}
+	return 0
+}
+
+func (s *stream) String() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buf.String()
+}
+
+// lookPath resolves file against the PATH of the isolated environment instead of our own
+func lookPath(file, pathEnv string) (string, error) {
+	if strings.ContainsAny(file, `/\`) {
+		return file, nil
+	}
+
+	extensions := []string{""}
+	if gosruntime.GOOS == "windows" && filepath.Ext(file) == "" {
+		extensions = strings.Split(strings.ToLower(envValue(os.Environ(), "PATHEXT")), ";")
+		if len(extensions) == 1 && extensions[0] == "" {
+			extensions = []string{".com", ".exe", ".bat", ".cmd"}
+		}
+	}
+
+	for _, dir := range filepath.SplitList(pathEnv) {
+		for _, ext := range extensions {
+			path := filepath.Join(dir, file+ext)
+			stat, err := os.Stat(path)
+			if err != nil || stat.IsDir() {
+				continue
+			}
+			if gosruntime.GOOS != "windows" && stat.Mode().Perm()&0o111 == 0 {
+				continue
+			}
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found on PATH", file)
+}
+
+// envValue returns the value of key in an environment list
+func envValue(env []string, key string) string {
+	for _, entry := range env {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if name == key || (gosruntime.GOOS == "windows" && strings.EqualFold(name, key)) {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStreamKeepsCharactersWhole(t *testing.T) {
+	var chunks []string
+	s := &stream{onData: func(data string) { chunks = append(chunks, data) }}
+
+	// "é" is 0xc3 0xa9 and "€" is 0xe2 0x82 0xac
+	for _, write := range []string{"caf\xc3", "\xa9 ", "\xe2", "\x82", "\xac!", "end\xe2\x82"} {
+		if n, err := s.Write([]byte(write)); n != len(write) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", write, n, err)
+		}
+	}
+	s.flush()
+
+	want := []string{"caf", "é ", "€!", "end", "\xe2\x82"}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Fatalf("chunks %q, want %q", chunks, want)
+	}
+	if got := s.String(); got != "café €!end\xe2\x82" {
+		t.Fatalf("captured %q", got)
+	}
+}
+
+func TestStreamCaptureLimit(t *testing.T) {
+	s := &stream{}
+	s.Write([]byte(strings.Repeat("a", maxCapturedOutput-1)))
+	s.Write([]byte("éa"))
+	s.Write([]byte("b"))
+
+	// The character that does not fit is dropped whole and nothing is captured after it
+	if got := s.String(); len(got) != maxCapturedOutput-1 || strings.Trim(got, "a") != "" {
+		t.Fatalf("captured %d bytes ending in %q", len(got), got[len(got)-4:])
+	}
+}
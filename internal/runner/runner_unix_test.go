@@ -0,0 +1,137 @@
+//go:build !windows
+
+package runner
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// collector gathers callback output, which arrives on the goroutines reading the pipes
+type collector struct {
+	mutex sync.Mutex
+	data  strings.Builder
+}
+
+func (c *collector) add(data string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.data.WriteString(data)
+}
+
+func (c *collector) String() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.data.String()
+}
+
+func TestRunExitCodeAndStreams(t *testing.T) {
+	var stdout, stderr collector
+	result, err := Run(context.Background(), Options{
+		Args:     []string{"/bin/sh", "-c", "echo out; echo err >&2; exit 3"},
+		OnStdout: stdout.add,
+		OnStderr: stderr.add,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 || result.TimedOut || result.Canceled || result.Signal != "" {
+		t.Fatalf("result %+v", result)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" {
+		t.Fatalf("stdout %q, stderr %q", result.Stdout, result.Stderr)
+	}
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Fatalf("callbacks got stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestRunMissingCommand(t *testing.T) {
+	if _, err := Run(context.Background(), Options{Args: []string{"enty-no-such-command"}}); err == nil {
+		t.Fatal("missing command did not fail")
+	}
+	if _, err := Run(context.Background(), Options{}); err == nil {
+		t.Fatal("empty command did not fail")
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	result, err := Run(context.Background(), Options{
+		Args:    []string{"/bin/sh", "-c", "sleep 30"},
+		Timeout: 200 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimedOut || result.Canceled || result.Signal != "killed" {
+		t.Fatalf("result %+v, want timed out and killed", result)
+	}
+	if result.DurationMs > 5000 {
+		t.Fatalf("command ran for %dms", result.DurationMs)
+	}
+}
+
+func TestCancelKillsProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The shell prints the pid of a background child, then waits for it
+	childPID := make(chan int, 1)
+	var output collector
+	done := make(chan *Result, 1)
+	go func() {
+		result, err := Run(ctx, Options{
+			Args: []string{"/bin/sh", "-c", "sleep 30 & echo $!; wait"},
+			OnStdout: func(data string) {
+				output.add(data)
+				if pid, err := strconv.Atoi(strings.TrimSpace(output.String())); err == nil {
+					childPID <- pid
+				}
+			},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- result
+	}()
+
+	var pid int
+	select {
+	case pid = <-childPID:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no child pid, output %q", output.String())
+	}
+	cancel()
+
+	result := <-done
+	if result == nil || !result.Canceled || result.TimedOut {
+		t.Fatalf("result %+v, want canceled", result)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for processExists(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("child %d still running after cancel", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// processExists reports whether pid is running; zombies waiting for init to reap them count as gone
+func processExists(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
@@ -0,0 +1,214 @@
+package scaffold
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// DatabaseCredentials are the connection details of a provisioned project database
+type DatabaseCredentials struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// databaseCleanupTimeout bounds dropping a database after a failed scaffold
+const databaseCleanupTimeout = 30 * time.Second
+
+// mysqlQuery runs statements as the administrative user; tests replace it to avoid a MySQL server
+var mysqlQuery = runMySQL
+
+// checkDatabase refuses to share a database or user with another project, including names cut to the same prefix
+func checkDatabase(ctx context.Context, job *Job) error {
+	name := databaseIdentifier(job.Name)
+	existing, err := mysqlQuery(ctx, job, mysqlSettings(), fmt.Sprintf(
+		"SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = '%s' "+
+			"UNION ALL SELECT User FROM mysql.user WHERE User = '%s'", name, name))
+	if err != nil {
+		return fmt.Errorf("failed to check MySQL databases: %w", err)
+	}
+	if strings.TrimSpace(existing) != "" {
+		return fmt.Errorf("a MySQL database or user named %s already exists; choose another project name", name)
+	}
+	return nil
+}
+
+// provisionDatabase creates a database and a dedicated user for the project with the mysql client.
+// On failure, whatever it created is dropped again.
+func provisionDatabase(ctx context.Context, job *Job) (*DatabaseCredentials, error) {
+	settings := mysqlSettings()
+
+	name := databaseIdentifier(job.Name)
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &DatabaseCredentials{
+		Host:     settings.Host,
+		Port:     settings.Port,
+		Database: name,
+		Username: name,
+		Password: password,
+	}
+
+	// Each object is created on its own so only those this call created are dropped.
+	// Without IF NOT EXISTS, a database or user created since checkDatabase makes this fail.
+	var drops []string
+	for _, step := range []struct{ create, drop string }{
+		{
+			fmt.Sprintf("CREATE USER '%s'@'localhost' IDENTIFIED BY '%s'", name, password),
+			fmt.Sprintf("DROP USER IF EXISTS '%s'@'localhost'", name),
+		},
+		{
+			fmt.Sprintf("CREATE USER '%s'@'127.0.0.1' IDENTIFIED BY '%s'", name, password),
+			fmt.Sprintf("DROP USER IF EXISTS '%s'@'127.0.0.1'", name),
+		},
+		{
+			fmt.Sprintf("CREATE DATABASE `%s` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci", name),
+			fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name),
+		},
+	} {
+		if _, err := mysqlQuery(ctx, job, settings, step.create); err != nil {
+			dropObjects(job, settings, name, drops)
+			return nil, fmt.Errorf("failed to provision database: %w", err)
+		}
+		drops = append(drops, step.drop)
+	}
+
+	grants := strings.Join([]string{
+		fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO '%s'@'localhost'", name, name),
+		fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO '%s'@'127.0.0.1'", name, name),
+		"FLUSH PRIVILEGES",
+	}, "; ")
+	if _, err := mysqlQuery(ctx, job, settings, grants); err != nil {
+		dropObjects(job, settings, name, drops)
+		return nil, fmt.Errorf("failed to provision database: %w", err)
+	}
+
+	job.Report("database", fmt.Sprintf("Created database %s with user %s", name, name))
+	return credentials, nil
+}
+
+// dropDatabase removes the database and users provisioned for a scaffold that failed later on
+func dropDatabase(job *Job) {
+	name := job.Credentials.Database
+	dropObjects(job, mysqlSettings(), name, []string{
+		fmt.Sprintf("DROP USER IF EXISTS '%s'@'localhost'", name),
+		fmt.Sprintf("DROP USER IF EXISTS '%s'@'127.0.0.1'", name),
+		fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", name),
+	})
+}
+
+// dropObjects runs drop statements in reverse order of creation. It does not use the scaffold's
+// context, so cleanup still happens when scaffolding was cancelled.
+func dropObjects(job *Job, settings config.MySQLSettings, name string, drops []string) {
+	if len(drops) == 0 {
+		return
+	}
+
+	statements := make([]string, 0, len(drops))
+	for i := len(drops) - 1; i >= 0; i-- {
+		statements = append(statements, drops[i])
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), databaseCleanupTimeout)
+	defer cancel()
+	if _, err := mysqlQuery(ctx, job, settings, strings.Join(statements, "; ")); err != nil {
+		job.Report("database", fmt.Sprintf("Could not remove database %s: %v", name, err))
+		return
+	}
+	job.Report("database", fmt.Sprintf("Removed database %s", name))
+}
+
+// runMySQL runs statements as the administrative user with the mysql client and returns its output.
+// The root password is passed in a private option file, so it does not appear in the process list.
+func runMySQL(ctx context.Context, job *Job, settings config.MySQLSettings, statements string) (string, error) {
+	optionsFile, err := os.CreateTemp("", "enty-mysql-*.cnf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create mysql option file: %w", err)
+	}
+	defer os.Remove(optionsFile.Name())
+
+	options := "[client]\n"
+	if settings.RootPassword != "" {
+		options += "password=\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(settings.RootPassword) + "\"\n"
+	}
+	_, err = optionsFile.WriteString(options)
+	if closeErr := optionsFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write mysql option file: %w", err)
+	}
+
+	// --defaults-extra-file must come first
+	cmd := exec.CommandContext(ctx, "mysql",
+		"--defaults-extra-file="+optionsFile.Name(),
+		"--protocol=TCP",
+		"-h", settings.Host,
+		"-P", strconv.Itoa(settings.Port),
+		"-u", settings.RootUser,
+		"--batch", "--skip-column-names",
+		"-e", statements,
+	)
+	cmd.Env = utils.BuildIsolatedEnv(utils.IsolatedEnvOptions{ServicePins: job.Services})
+	if path, ok := lookPathIn("mysql", cmd.Env); ok {
+		cmd.Path = path
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("mysql failed: %s", message)
+		}
+		return "", fmt.Errorf("mysql failed: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// databaseIdentifier turns a project name into a safe MySQL database and user name
+func databaseIdentifier(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+
+	identifier := strings.Trim(sb.String(), "_")
+	if identifier == "" {
+		identifier = "project"
+	}
+	// MySQL user names are limited to 32 characters
+	if len(identifier) > 32 {
+		identifier = identifier[:32]
+	}
+	return identifier
+}
+
+func randomPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
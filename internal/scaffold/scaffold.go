@@ -0,0 +1,348 @@
+// Package scaffold creates new local sites from built-in project templates.
+package scaffold
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/hosts"
+	"github.com/JadlionHD/Enty/internal/project"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// Options describes the site to scaffold
+type Options struct {
+	Template string `json:"template"`
+	Name     string `json:"name"`
+	// Root is the directory to create; it must not exist or be empty
+	Root     string            `json:"root"`
+	Hostname string            `json:"hostname"`
+	Services map[string]string `json:"services"`
+	// Database provisions a MySQL database and user when the template supports it
+	Database bool `json:"database"`
+}
+
+// Progress is a single update emitted while scaffolding
+type Progress struct {
+	Step    string `json:"step"`
+	Message string `json:"message"`
+	Done    bool   `json:"done"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Job is the state shared with a template while it runs
+type Job struct {
+	Options
+	Credentials *DatabaseCredentials
+	progress    chan<- Progress
+}
+
+// Template creates the files of one kind of project
+type Template interface {
+	Name() string
+	Description() string
+	// UsesDatabase reports whether the template writes database credentials
+	UsesDatabase() bool
+	// Create populates job.Root with the project files
+	Create(ctx context.Context, job *Job) error
+	// Configure writes environment files once credentials are known
+	Configure(job *Job) error
+}
+
+// TemplateInfo describes a registered template for the frontend
+type TemplateInfo struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	UsesDatabase bool   `json:"usesDatabase"`
+}
+
+// Engine runs templates and registers the resulting projects
+type Engine struct {
+	projects  *project.Registry
+	hosts     *hosts.Manager
+	templates map[string]Template
+	mutex     sync.RWMutex
+}
+
+// NewEngine creates an engine with the built-in templates registered
+func NewEngine(projects *project.Registry, hostsManager *hosts.Manager) *Engine {
+	e := &Engine{
+		projects:  projects,
+		hosts:     hostsManager,
+		templates: make(map[string]Template),
+	}
+	for _, template := range builtinTemplates() {
+		e.Register(template)
+	}
+	return e
+}
+
+// Register adds or replaces a template
+func (e *Engine) Register(template Template) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.templates[template.Name()] = template
+}
+
+// Templates returns the registered templates sorted by name
+func (e *Engine) Templates() []TemplateInfo {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	infos := make([]TemplateInfo, 0, len(e.templates))
+	for _, template := range e.templates {
+		infos = append(infos, TemplateInfo{
+			Name:         template.Name(),
+			Description:  template.Description(),
+			UsesDatabase: template.UsesDatabase(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Run scaffolds a project, streaming progress until the channel is closed.
+// The final update has Done set, with Error filled in on failure.
+func (e *Engine) Run(ctx context.Context, opts Options, progress chan<- Progress) (*project.Project, error) {
+	defer close(progress)
+
+	created, err := e.run(ctx, opts, progress)
+	if err != nil {
+		progress <- Progress{Step: "failed", Message: "Scaffolding failed", Done: true, Error: err.Error()}
+		return nil, err
+	}
+
+	progress <- Progress{Step: "done", Message: fmt.Sprintf("Project %s is ready", created.Name), Done: true}
+	return created, nil
+}
+
+func (e *Engine) run(ctx context.Context, opts Options, progress chan<- Progress) (*project.Project, error) {
+	e.mutex.RLock()
+	template, exists := e.templates[opts.Template]
+	e.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown template: %s", opts.Template)
+	}
+
+	root, err := checkRoot(opts.Root)
+	if err != nil {
+		return nil, err
+	}
+	opts.Root = root
+	if opts.Name == "" {
+		opts.Name = filepath.Base(root)
+	}
+	if opts.Hostname != "" {
+		if err := hosts.ValidateHostname(opts.Hostname); err != nil {
+			return nil, err
+		}
+	}
+
+	job := &Job{Options: opts, progress: progress}
+	provision := opts.Database && template.UsesDatabase()
+
+	// Refuse conflicts before anything is created, so a failure leaves nothing to undo
+	if err := e.projects.CheckAvailable(project.Project{Root: root, Hostname: opts.Hostname}); err != nil {
+		return nil, err
+	}
+	if provision {
+		if err := checkDatabase(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	root, created, err := prepareRoot(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave no half-written project or orphaned database behind when a step fails
+	succeeded := false
+	defer func() {
+		if succeeded {
+			return
+		}
+		if job.Credentials != nil {
+			dropDatabase(job)
+		}
+		removeRoot(root, created)
+	}()
+
+	job.Report("create", fmt.Sprintf("Creating %s project in %s", template.Name(), root))
+	if err := template.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if provision {
+		job.Report("database", "Provisioning MySQL database")
+		credentials, err := provisionDatabase(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		job.Credentials = credentials
+	}
+
+	job.Report("configure", "Writing configuration")
+	if err := template.Configure(job); err != nil {
+		return nil, err
+	}
+
+	job.Report("register", "Registering project")
+	registered, err := e.projects.Add(project.Project{
+		Name:     opts.Name,
+		Root:     root,
+		Hostname: opts.Hostname,
+		Services: opts.Services,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Hostname != "" && e.hosts != nil {
+		job.Report("hosts", fmt.Sprintf("Adding %s to the hosts file", opts.Hostname))
+		if err := e.hosts.Add(hosts.Entry{IP: "127.0.0.1", Hostname: opts.Hostname}); err != nil {
+			// The project is usable without the hosts entry, so only warn
+			job.Report("hosts", fmt.Sprintf("Could not update hosts file: %v", err))
+		}
+	}
+
+	succeeded = true
+	return registered, nil
+}
+
+// Report sends a progress update for the current step
+func (job *Job) Report(step, message string) {
+	job.progress <- Progress{Step: step, Message: message}
+}
+
+// Command runs a program inside the project using the project's isolated environment,
+// streaming its output as progress messages
+func (job *Job) Command(ctx context.Context, dir string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = utils.BuildIsolatedEnv(utils.IsolatedEnvOptions{ServicePins: job.Services})
+
+	// Resolve the program against the isolated PATH rather than Enty's own
+	if path, ok := lookPathIn(name, cmd.Env); ok {
+		cmd.Path = path
+	}
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", name, err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				job.Report("command", line)
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	writer.Close()
+	<-done
+
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// checkRoot resolves the project directory and ensures it is missing or empty
+func checkRoot(root string) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("project directory is required")
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid project directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err == nil && len(entries) > 0 {
+		return "", fmt.Errorf("project directory is not empty: %s", root)
+	}
+	return root, nil
+}
+
+// prepareRoot ensures the project directory exists and is empty, reporting whether it was created
+func prepareRoot(root string) (string, bool, error) {
+	root, err := checkRoot(root)
+	if err != nil {
+		return "", false, err
+	}
+
+	_, err = os.Stat(root)
+	created := os.IsNotExist(err)
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return "", false, fmt.Errorf("failed to create project directory: %w", err)
+	}
+	return root, created, nil
+}
+
+// removeRoot deletes a project directory created by prepareRoot, or empties one that already existed
+func removeRoot(root string, created bool) {
+	if created {
+		os.RemoveAll(root)
+		return
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		os.RemoveAll(filepath.Join(root, entry.Name()))
+	}
+}
+
+// lookPathIn finds an executable on the PATH of env
+func lookPathIn(name string, env []string) (string, bool) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return name, true
+	}
+
+	var pathList string
+	for _, entry := range env {
+		if key, value, ok := strings.Cut(entry, "="); ok && strings.EqualFold(key, "PATH") {
+			pathList = value
+		}
+	}
+
+	extensions := []string{""}
+	if pathExt := os.Getenv("PATHEXT"); pathExt != "" {
+		extensions = append(extensions, strings.Split(strings.ToLower(pathExt), string(os.PathListSeparator))...)
+	}
+
+	for _, dir := range filepath.SplitList(pathList) {
+		for _, ext := range extensions {
+			candidate := filepath.Join(dir, name+ext)
+			if stat, err := os.Stat(candidate); err == nil && !stat.IsDir() {
+				return candidate, true
+			}
+		}
+	}
+	return "", false
+}
+
+// mysqlSettings returns the administrative MySQL connection settings
+func mysqlSettings() config.MySQLSettings {
+	return config.LiveSettingsManager().Get().MySQL
+}
@@ -0,0 +1,234 @@
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/project"
+)
+
+// fakeTemplate records whether it ran and fails Configure when told to
+type fakeTemplate struct {
+	created      bool
+	configureErr error
+}
+
+func (t *fakeTemplate) Name() string        { return "fake" }
+func (t *fakeTemplate) Description() string { return "Test template" }
+func (t *fakeTemplate) UsesDatabase() bool  { return true }
+
+func (t *fakeTemplate) Create(ctx context.Context, job *Job) error {
+	t.created = true
+	return os.WriteFile(filepath.Join(job.Root, "index.php"), []byte("<?php"), 0o644)
+}
+
+func (t *fakeTemplate) Configure(job *Job) error { return t.configureErr }
+
+// fakeMySQL records statements instead of running the mysql client
+type fakeMySQL struct {
+	mutex      sync.Mutex
+	statements []string
+	// respond returns the output for a statement, or an error to fail it
+	respond func(statements string) (string, error)
+}
+
+func useFakeMySQL(t *testing.T, respond func(statements string) (string, error)) *fakeMySQL {
+	t.Helper()
+	t.Setenv("ENTY_CONFIG_DIR", t.TempDir())
+	fake := &fakeMySQL{respond: respond}
+	mysqlQuery = func(ctx context.Context, job *Job, settings config.MySQLSettings, statements string) (string, error) {
+		fake.mutex.Lock()
+		fake.statements = append(fake.statements, statements)
+		fake.mutex.Unlock()
+		if fake.respond != nil {
+			return fake.respond(statements)
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { mysqlQuery = runMySQL })
+	return fake
+}
+
+// ran returns the statements starting with prefix
+func (f *fakeMySQL) ran(prefix string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var matched []string
+	for _, statement := range f.statements {
+		if strings.HasPrefix(statement, prefix) {
+			matched = append(matched, statement)
+		}
+	}
+	return matched
+}
+
+// newTestEngine returns an engine with only template registered and an empty project registry
+func newTestEngine(t *testing.T, template Template) (*Engine, *project.Registry) {
+	t.Helper()
+	registry, err := project.NewRegistry(filepath.Join(t.TempDir(), "projects.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Engine{projects: registry, templates: make(map[string]Template)}
+	e.Register(template)
+	return e, registry
+}
+
+// runScaffold runs the engine, discarding progress
+func runScaffold(e *Engine, opts Options) (*project.Project, error) {
+	progress := make(chan Progress)
+	go func() {
+		for range progress {
+		}
+	}()
+	return e.Run(context.Background(), opts, progress)
+}
+
+func TestPHPString(t *testing.T) {
+	cases := map[string]string{
+		`Shop`:          `'Shop'`,
+		`$HOME {$x}`:    `'$HOME {$x}'`,
+		`it's`:          `'it\'s'`,
+		`C:\sites\new\`: `'C:\\sites\\new\\'`,
+	}
+	for in, want := range cases {
+		if got := phpString(in); got != want {
+			t.Errorf("phpString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestRemoveRoot(t *testing.T) {
+	parent := t.TempDir()
+
+	root, created, err := prepareRoot(filepath.Join(parent, "new-site"))
+	if err != nil || !created {
+		t.Fatalf("prepareRoot = %v, %v", created, err)
+	}
+	os.WriteFile(filepath.Join(root, "index.php"), []byte("<?php"), 0o644)
+	removeRoot(root, created)
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("created directory still exists: %v", err)
+	}
+
+	// A directory the user created beforehand is emptied but kept
+	existing := filepath.Join(parent, "existing")
+	os.Mkdir(existing, 0o755)
+	root, created, err = prepareRoot(existing)
+	if err != nil || created {
+		t.Fatalf("prepareRoot = %v, %v", created, err)
+	}
+	os.MkdirAll(filepath.Join(root, "vendor", "pkg"), 0o755)
+	removeRoot(root, created)
+	entries, err := os.ReadDir(existing)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("existing directory: %v entries, %v", len(entries), err)
+	}
+}
+
+func TestConflictsCheckedBeforeSideEffects(t *testing.T) {
+	template := &fakeTemplate{}
+	e, registry := newTestEngine(t, template)
+	mysql := useFakeMySQL(t, nil)
+
+	existing := t.TempDir()
+	if _, err := registry.Add(project.Project{Name: "shop", Root: existing, Hostname: "shop.test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(t.TempDir(), "new-shop")
+	_, err := runScaffold(e, Options{Template: "fake", Root: root, Hostname: "SHOP.test", Database: true})
+	if err == nil || !strings.Contains(err.Error(), "shop.test") {
+		t.Fatalf("hostname conflict: %v", err)
+	}
+	if template.created || len(mysql.ran("")) != 0 {
+		t.Fatalf("side effects before the conflict check: created %v, mysql %q", template.created, mysql.statements)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("project directory was created: %v", err)
+	}
+}
+
+func TestExistingDatabaseCheckedBeforeSideEffects(t *testing.T) {
+	template := &fakeTemplate{}
+	e, _ := newTestEngine(t, template)
+	mysql := useFakeMySQL(t, func(statements string) (string, error) {
+		if strings.HasPrefix(statements, "SELECT") {
+			return "shop\n", nil
+		}
+		return "", nil
+	})
+
+	root := filepath.Join(t.TempDir(), "shop")
+	_, err := runScaffold(e, Options{Template: "fake", Root: root, Database: true})
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("database conflict: %v", err)
+	}
+	if template.created || len(mysql.ran("CREATE")) != 0 {
+		t.Fatalf("side effects before the database check: created %v, mysql %q", template.created, mysql.statements)
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("project directory was created: %v", err)
+	}
+}
+
+func TestFailureDropsProvisionedDatabase(t *testing.T) {
+	template := &fakeTemplate{configureErr: errors.New("cannot write .env")}
+	e, registry := newTestEngine(t, template)
+	mysql := useFakeMySQL(t, nil)
+
+	root := filepath.Join(t.TempDir(), "shop")
+	if _, err := runScaffold(e, Options{Template: "fake", Root: root, Database: true}); err == nil {
+		t.Fatal("scaffold succeeded")
+	}
+
+	if len(mysql.ran("CREATE")) != 3 {
+		t.Fatalf("created %q", mysql.ran("CREATE"))
+	}
+	drops := mysql.ran("DROP")
+	if len(drops) != 1 {
+		t.Fatalf("drops %q", drops)
+	}
+	for _, want := range []string{"DROP DATABASE IF EXISTS `shop`", "'shop'@'localhost'", "'shop'@'127.0.0.1'"} {
+		if !strings.Contains(drops[0], want) {
+			t.Fatalf("drop %q does not contain %s", drops[0], want)
+		}
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Fatalf("project directory was kept: %v", err)
+	}
+	if len(registry.List()) != 0 {
+		t.Fatalf("project registered: %+v", registry.List())
+	}
+}
+
+func TestPartialProvisioningDropsOnlyCreated(t *testing.T) {
+	template := &fakeTemplate{}
+	e, _ := newTestEngine(t, template)
+	mysql := useFakeMySQL(t, func(statements string) (string, error) {
+		if strings.HasPrefix(statements, "CREATE DATABASE") {
+			return "", errors.New("database exists")
+		}
+		return "", nil
+	})
+
+	root := filepath.Join(t.TempDir(), "shop")
+	if _, err := runScaffold(e, Options{Template: "fake", Root: root, Database: true}); err == nil {
+		t.Fatal("scaffold succeeded")
+	}
+
+	drops := mysql.ran("DROP")
+	if len(drops) != 1 {
+		t.Fatalf("drops %q", drops)
+	}
+	want := "DROP USER IF EXISTS 'shop'@'127.0.0.1'; DROP USER IF EXISTS 'shop'@'localhost'"
+	if drops[0] != want {
+		t.Fatalf("drop %q, want %q", drops[0], want)
+	}
+}
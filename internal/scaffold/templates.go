@@ -0,0 +1,303 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const wordpressDownloadURL = "https://wordpress.org/latest.tar.gz"
+
+func builtinTemplates() []Template {
+	return []Template{
+		&laravelTemplate{},
+		&wordpressTemplate{},
+		&phpTemplate{},
+		&nodeTemplate{},
+	}
+}
+
+// laravelTemplate creates a Laravel application with composer
+type laravelTemplate struct{}
+
+func (t *laravelTemplate) Name() string        { return "laravel" }
+func (t *laravelTemplate) Description() string { return "Laravel application created with composer" }
+func (t *laravelTemplate) UsesDatabase() bool  { return true }
+
+func (t *laravelTemplate) Create(ctx context.Context, job *Job) error {
+	job.Report("create", "Running composer create-project laravel/laravel")
+	return job.Command(ctx, job.Root, "composer", "create-project", "--no-interaction", "laravel/laravel", ".")
+}
+
+func (t *laravelTemplate) Configure(job *Job) error {
+	values := map[string]string{
+		"APP_NAME": strconv.Quote(job.Name),
+	}
+	if job.Hostname != "" {
+		values["APP_URL"] = "http://" + job.Hostname
+	}
+	if c := job.Credentials; c != nil {
+		values["DB_CONNECTION"] = "mysql"
+		values["DB_HOST"] = c.Host
+		values["DB_PORT"] = strconv.Itoa(c.Port)
+		values["DB_DATABASE"] = c.Database
+		values["DB_USERNAME"] = c.Username
+		values["DB_PASSWORD"] = c.Password
+	}
+	return setEnvValues(filepath.Join(job.Root, ".env"), values)
+}
+
+// wordpressTemplate downloads the latest WordPress release
+type wordpressTemplate struct{}
+
+func (t *wordpressTemplate) Name() string        { return "wordpress" }
+func (t *wordpressTemplate) Description() string { return "Latest WordPress from wordpress.org" }
+func (t *wordpressTemplate) UsesDatabase() bool  { return true }
+
+func (t *wordpressTemplate) Create(ctx context.Context, job *Job) error {
+	job.Report("download", "Downloading "+wordpressDownloadURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", wordpressDownloadURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download WordPress: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	job.Report("extract", "Extracting WordPress")
+	return extractTarGz(resp.Body, job.Root, "wordpress/")
+}
+
+func (t *wordpressTemplate) Configure(job *Job) error {
+	sample, err := os.ReadFile(filepath.Join(job.Root, "wp-config-sample.php"))
+	if err != nil {
+		return fmt.Errorf("failed to read wp-config-sample.php: %w", err)
+	}
+
+	config := string(sample)
+	if c := job.Credentials; c != nil {
+		config = strings.NewReplacer(
+			"database_name_here", c.Database,
+			"username_here", c.Username,
+			"password_here", c.Password,
+			"'localhost'", fmt.Sprintf("'%s:%d'", c.Host, c.Port),
+		).Replace(config)
+	}
+
+	// Replace every "put your unique phrase here" placeholder with its own random salt
+	for strings.Contains(config, "put your unique phrase here") {
+		salt, err := randomSalt()
+		if err != nil {
+			return err
+		}
+		config = strings.Replace(config, "put your unique phrase here", salt, 1)
+	}
+
+	return os.WriteFile(filepath.Join(job.Root, "wp-config.php"), []byte(config), 0o644)
+}
+
+// phpTemplate creates a plain PHP site
+type phpTemplate struct{}
+
+func (t *phpTemplate) Name() string        { return "php" }
+func (t *phpTemplate) Description() string { return "Plain PHP site with an index.php" }
+func (t *phpTemplate) UsesDatabase() bool  { return true }
+
+func (t *phpTemplate) Create(ctx context.Context, job *Job) error {
+	index := fmt.Sprintf(`<?php
+
+$name = %s;
+
+?>
+<!DOCTYPE html>
+<html>
+<head>
+    <title><?= htmlspecialchars($name) ?></title>
+</head>
+<body>
+    <h1><?= htmlspecialchars($name) ?></h1>
+    <p>Running PHP <?= PHP_VERSION ?></p>
+</body>
+</html>
+`, phpString(job.Name))
+	return os.WriteFile(filepath.Join(job.Root, "index.php"), []byte(index), 0o644)
+}
+
+func (t *phpTemplate) Configure(job *Job) error {
+	c := job.Credentials
+	if c == nil {
+		return nil
+	}
+	return setEnvValues(filepath.Join(job.Root, ".env"), map[string]string{
+		"DB_HOST":     c.Host,
+		"DB_PORT":     strconv.Itoa(c.Port),
+		"DB_DATABASE": c.Database,
+		"DB_USERNAME": c.Username,
+		"DB_PASSWORD": c.Password,
+	})
+}
+
+// phpString quotes s as a single-quoted PHP literal, in which only \\ and \' are special
+func phpString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// nodeTemplate creates a minimal Express application
+type nodeTemplate struct{}
+
+func (t *nodeTemplate) Name() string        { return "node" }
+func (t *nodeTemplate) Description() string { return "Node.js application with Express" }
+func (t *nodeTemplate) UsesDatabase() bool  { return false }
+
+func (t *nodeTemplate) Create(ctx context.Context, job *Job) error {
+	packageJSON := fmt.Sprintf(`{
+  "name": %q,
+  "version": "1.0.0",
+  "private": true,
+  "main": "index.js",
+  "scripts": {
+    "start": "node index.js"
+  }
+}
+`, databaseIdentifier(job.Name))
+
+	index := `const express = require('express')
+
+const app = express()
+const port = process.env.PORT || 3000
+
+app.get('/', (req, res) => {
+  res.send('Hello from Enty!')
+})
+
+app.listen(port, () => {
+  console.log(` + "`Listening on http://localhost:${port}`" + `)
+})
+`
+
+	if err := os.WriteFile(filepath.Join(job.Root, "package.json"), []byte(packageJSON), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(job.Root, "index.js"), []byte(index), 0o644); err != nil {
+		return err
+	}
+
+	job.Report("create", "Running npm install express")
+	return job.Command(ctx, job.Root, "npm", "install", "express")
+}
+
+func (t *nodeTemplate) Configure(job *Job) error {
+	return setEnvValues(filepath.Join(job.Root, ".env"), map[string]string{
+		"PORT": "3000",
+	})
+}
+
+// setEnvValues updates KEY=VALUE lines in a dotenv file, appending keys that are missing
+func setEnvValues(path string, values map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	written := make(map[string]bool)
+	for i, line := range lines {
+		key, _, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "# "), "=")
+		if value, exists := values[key]; ok && exists && !written[key] {
+			lines[i] = key + "=" + value
+			written[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+"="+values[key])
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+// extractTarGz extracts a gzipped tarball into dest, removing stripPrefix from entry names
+func extractTarGz(r io.Reader, dest, stripPrefix string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		name := strings.TrimPrefix(header.Name, stripPrefix)
+		if name == "" {
+			continue
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if rel, err := filepath.Rel(dest, target); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("archive entry escapes destination: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		}
+	}
+}
+
+func randomSalt() (string, error) {
+	buf := make([]byte, 48)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,19 @@
+//go:build linux
+
+package service
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processExecutable returns the path of the program a process runs
+func processExecutable(pid int) (string, bool) {
+	path, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/exe")
+	if err != nil {
+		return "", false
+	}
+	// The binary may have been replaced by an upgrade while the daemon kept running
+	return strings.TrimSuffix(path, " (deleted)"), true
+}
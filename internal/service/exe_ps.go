@@ -0,0 +1,19 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processExecutable returns the program a process runs, asking ps(1) as these systems have no /proc
+func processExecutable(pid int) (string, bool) {
+	output, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", false
+	}
+	path := strings.TrimSpace(string(output))
+	return path, path != ""
+}
@@ -0,0 +1,272 @@
+// Package service starts and stops long-running service daemons (such as mysqld) in the
+// background. State is kept in PID files so the GUI and the CLI see the same processes.
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	gosruntime "runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// stopTimeout is how long Stop waits for a graceful shutdown before killing the process
+const stopTimeout = 15 * time.Second
+
+// Status describes the state of a managed service
+type Status struct {
+	Service string `json:"service"`
+	Running bool   `json:"running"`
+	PID     int    `json:"pid,omitempty"`
+	Path    string `json:"path,omitempty"`
+	LogFile string `json:"logFile,omitempty"`
+}
+
+// definition describes how to run a service binary found in an installed version directory
+type definition struct {
+	binary string
+	// prepare runs once before the first start, e.g. to initialize a data directory
+	prepare func(executable, root, dataDir string) error
+	args    func(root, dataDir, runDir string) []string
+}
+
+var definitions = map[string]definition{
+	"mysql": {
+		binary: "mysqld",
+		prepare: func(executable, root, dataDir string) error {
+			if entries, err := os.ReadDir(dataDir); err == nil && len(entries) > 0 {
+				return nil
+			}
+			output, err := exec.Command(executable, "--no-defaults", "--initialize-insecure",
+				"--basedir="+root, "--datadir="+dataDir).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("failed to initialize MySQL data directory: %v: %s", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		},
+		args: func(root, dataDir, runDir string) []string {
+			settings := config.LiveSettingsManager().Get().MySQL
+			args := []string{
+				"--no-defaults",
+				"--basedir=" + root,
+				"--datadir=" + dataDir,
+				"--bind-address=" + settings.Host,
+				"--port=" + strconv.Itoa(settings.Port),
+				"--mysqlx=OFF",
+			}
+			if gosruntime.GOOS != "windows" {
+				args = append(args, "--socket="+filepath.Join(runDir, "mysql.sock"))
+			}
+			return args
+		},
+	},
+}
+
+// Supported returns the names of the services that can be started
+func Supported() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start launches a service in the background. version selects an installed version,
+// otherwise the active service path from paths.json is used.
+func Start(serviceName, version string) (*Status, error) {
+	serviceName = strings.ToLower(serviceName)
+	def, ok := definitions[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be run as a service (supported: %s)", serviceName, strings.Join(Supported(), ", "))
+	}
+
+	if status := Get(serviceName); status.Running {
+		return status, fmt.Errorf("%s is already running (pid %d)", serviceName, status.PID)
+	}
+
+	root, err := servicePath(serviceName, version)
+	if err != nil {
+		return nil, err
+	}
+	executable, ok := findExecutable(root, def.binary)
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s", def.binary, root)
+	}
+
+	dataDir, err := config.DataPath("data", serviceName, filepath.Base(root))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	runDir := filepath.Dir(pidFile(serviceName))
+
+	if def.prepare != nil {
+		if err := def.prepare(executable, root, dataDir); err != nil {
+			return nil, err
+		}
+	}
+
+	logPath, err := config.DataPath("logs", serviceName+".log")
+	if err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, def.args(root, dataDir, runDir)...)
+	cmd.Dir = root
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", serviceName, err)
+	}
+	pid := cmd.Process.Pid
+	// Reap the daemon when it exits so a dead child does not linger as a zombie that still looks alive
+	go cmd.Wait()
+
+	if err := os.WriteFile(pidFile(serviceName), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+
+	return &Status{Service: serviceName, Running: true, PID: pid, Path: root, LogFile: logPath}, nil
+}
+
+// Stop asks a running service to shut down, killing it if it does not exit in time. Get
+// verifies that the pid still belongs to the service binary, so a stale PID file never
+// leads to signalling an unrelated process.
+func Stop(serviceName string) error {
+	serviceName = strings.ToLower(serviceName)
+	status := Get(serviceName)
+	if !status.Running {
+		os.Remove(pidFile(serviceName))
+		return fmt.Errorf("%s is not running", serviceName)
+	}
+
+	process, err := os.FindProcess(status.PID)
+	if err != nil {
+		return err
+	}
+	if err := terminate(process); err != nil {
+		return fmt.Errorf("failed to stop %s: %w", serviceName, err)
+	}
+
+	deadline := time.Now().Add(stopTimeout)
+	for ProcessAlive(status.PID) && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+	}
+	if ProcessAlive(status.PID) {
+		if err := process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill %s: %w", serviceName, err)
+		}
+	}
+
+	os.Remove(pidFile(serviceName))
+	return nil
+}
+
+// Get returns the status of a service from its PID file. A pid that is alive but now runs a
+// different program (the PID was reused after the service died) counts as not running.
+func Get(serviceName string) *Status {
+	serviceName = strings.ToLower(serviceName)
+	status := &Status{Service: serviceName}
+
+	data, err := os.ReadFile(pidFile(serviceName))
+	if err != nil {
+		return status
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || !ProcessAlive(pid) || !isServiceProcess(pid, definitions[serviceName].binary) {
+		return status
+	}
+
+	status.Running = true
+	status.PID = pid
+	if logPath, err := config.DataPath("logs", serviceName+".log"); err == nil {
+		status.LogFile = logPath
+	}
+	return status
+}
+
+// List returns the status of every supported service
+func List() []*Status {
+	statuses := make([]*Status, 0, len(definitions))
+	for _, name := range Supported() {
+		statuses = append(statuses, Get(name))
+	}
+	return statuses
+}
+
+// servicePath resolves the installation directory of a service
+func servicePath(serviceName, version string) (string, error) {
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
+	if err := pathsConfig.LoadConfig(); err != nil {
+		return "", err
+	}
+
+	if version != "" {
+		_, path, ok := pathsConfig.ResolveServiceVersion(serviceName, version)
+		if !ok {
+			return "", fmt.Errorf("%s %s is not installed", serviceName, version)
+		}
+		return path, nil
+	}
+
+	path, ok := pathsConfig.GetServicePath(serviceName)
+	if !ok {
+		return "", fmt.Errorf("no version of %s is configured", serviceName)
+	}
+	return path, nil
+}
+
+func pidFile(serviceName string) string {
+	path, err := config.DataPath("run", serviceName+".pid")
+	if err != nil {
+		return filepath.Join(os.TempDir(), "enty-"+serviceName+".pid")
+	}
+	return path
+}
+
+// isServiceProcess reports whether pid runs the given service binary. Processes whose
+// executable cannot be read are not ours, as the daemons run as the current user.
+func isServiceProcess(pid int, binary string) bool {
+	if binary == "" {
+		return false
+	}
+	executable, ok := processExecutable(pid)
+	if !ok {
+		return false
+	}
+	name := filepath.Base(executable)
+	if gosruntime.GOOS == "windows" {
+		return strings.EqualFold(strings.TrimSuffix(strings.ToLower(name), ".exe"), binary)
+	}
+	return name == binary
+}
+
+// findExecutable looks for binary in dir and dir/bin
+func findExecutable(dir, binary string) (string, bool) {
+	if gosruntime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	for _, candidate := range []string{filepath.Join(dir, "bin"), dir} {
+		path := filepath.Join(candidate, binary)
+		if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsServiceProcess(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := strings.TrimSuffix(filepath.Base(executable), ".exe")
+
+	if !isServiceProcess(os.Getpid(), binary) {
+		t.Fatalf("test process not recognised as %s", binary)
+	}
+	// A pid that was reused by another program is not the service
+	if isServiceProcess(os.Getpid(), "mysqld") {
+		t.Fatal("test process recognised as mysqld")
+	}
+	if isServiceProcess(1<<22+1, binary) {
+		t.Fatal("missing process recognised")
+	}
+}
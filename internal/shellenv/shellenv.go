@@ -0,0 +1,167 @@
+// Package shellenv prints the isolated service environment as shell statements,
+// so it can be loaded with eval "$(enty env)" or from a direnv .envrc.
+package shellenv
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"sort"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/project"
+	"github.com/JadlionHD/Enty/internal/utils"
+)
+
+// Command is the argument that switches the Enty executable into env mode
+const Command = "env"
+
+// Shells lists the supported output formats
+var Shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Build returns the variables that differ between the current environment and the isolated
+// environment for dir, using the nearest manifest and registered project pins
+func Build(dir string) (map[string]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make(map[string]string)
+	env := make(map[string]string)
+
+	if registryPath, err := config.DataPath("projects.json"); err == nil {
+		if registry, err := project.NewRegistry(registryPath); err == nil {
+			if p, ok := registry.FindByDir(dir); ok {
+				for service, version := range p.Services {
+					pins[service] = version
+				}
+			}
+		}
+	}
+
+	// The manifest is the source of truth when present, so it overrides registry pins
+	if manifestDir, ok := project.FindManifest(dir); ok {
+		manifest, err := project.LoadManifest(manifestDir)
+		if err != nil {
+			return nil, err
+		}
+		for service, version := range manifest.Services {
+			pins[service] = version
+		}
+		for key, value := range manifest.Env {
+			env[key] = value
+		}
+	}
+
+	isolated := utils.BuildIsolatedEnv(utils.IsolatedEnvOptions{
+		ServicePins: pins,
+		Env:         env,
+	})
+
+	changed := make(map[string]string)
+	for _, entry := range isolated {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if current, exists := os.LookupEnv(key); !exists || current != value {
+			changed[key] = value
+		}
+	}
+	return changed, nil
+}
+
+// Render formats vars as statements for the given shell
+func Render(shell string, vars map[string]string) (string, error) {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		value := vars[key]
+		switch shell {
+		case "bash", "zsh":
+			fmt.Fprintf(&sb, "export %s=%s\n", key, posixQuote(value))
+		case "fish":
+			if key == "PATH" {
+				// fish treats PATH as a list, one element per directory
+				parts := filepath.SplitList(value)
+				for i, part := range parts {
+					parts[i] = fishQuote(part)
+				}
+				fmt.Fprintf(&sb, "set -gx PATH %s;\n", strings.Join(parts, " "))
+				continue
+			}
+			fmt.Fprintf(&sb, "set -gx %s %s;\n", key, fishQuote(value))
+		case "powershell":
+			fmt.Fprintf(&sb, "$env:%s = %s\n", key, powershellQuote(value))
+		default:
+			return "", fmt.Errorf("unsupported shell %q (supported: %s)", shell, strings.Join(Shells, ", "))
+		}
+	}
+	return sb.String(), nil
+}
+
+// DetectShell guesses the user's shell from $SHELL, defaulting to powershell on Windows
+func DetectShell() string {
+	if gosruntime.GOOS == "windows" {
+		return "powershell"
+	}
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "zsh":
+		return "zsh"
+	case "fish":
+		return "fish"
+	default:
+		return "bash"
+	}
+}
+
+// Run handles "env [--project DIR] [--shell SHELL]" and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	projectDir := flags.String("project", ".", "project directory to build the environment for")
+	shell := flags.String("shell", DetectShell(), "output format: "+strings.Join(Shells, ", "))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	config.UseExecutableConfigDir()
+
+	vars, err := Build(*projectDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "enty: %v\n", err)
+		return 1
+	}
+
+	output, err := Render(*shell, vars)
+	if err != nil {
+		fmt.Fprintf(stderr, "enty: %v\n", err)
+		return 2
+	}
+
+	fmt.Fprint(stdout, output)
+	return 0
+}
+
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func fishQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+func powershellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,97 @@
+package shellenv
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	vars := map[string]string{
+		"APP_NAME": `it's "quoted"`,
+		"PHP_INI":  `/Users/me/My Sites/php.ini`,
+		"WIN_DIR":  `C:\Program Files\Enty\`,
+		"PATH":     "/opt/enty/php 8.2/bin" + sep + "/usr/bin",
+	}
+
+	cases := map[string]string{
+		"bash": `export APP_NAME='it'\''s "quoted"'
+export PATH='/opt/enty/php 8.2/bin` + sep + `/usr/bin'
+export PHP_INI='/Users/me/My Sites/php.ini'
+export WIN_DIR='C:\Program Files\Enty\'
+`,
+		"fish": `set -gx APP_NAME 'it\'s "quoted"';
+set -gx PATH '/opt/enty/php 8.2/bin' '/usr/bin';
+set -gx PHP_INI '/Users/me/My Sites/php.ini';
+set -gx WIN_DIR 'C:\\Program Files\\Enty\\';
+`,
+		"powershell": `$env:APP_NAME = 'it''s "quoted"'
+$env:PATH = '/opt/enty/php 8.2/bin` + sep + `/usr/bin'
+$env:PHP_INI = '/Users/me/My Sites/php.ini'
+$env:WIN_DIR = 'C:\Program Files\Enty\'
+`,
+	}
+	cases["zsh"] = cases["bash"]
+
+	for _, shell := range Shells {
+		want, ok := cases[shell]
+		if !ok {
+			t.Fatalf("no expected output for %s", shell)
+		}
+		got, err := Render(shell, vars)
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		if got != want {
+			t.Errorf("%s output:\n%s\nwant:\n%s", shell, got, want)
+		}
+	}
+
+	if _, err := Render("tcsh", vars); err == nil {
+		t.Fatal("unsupported shell accepted")
+	}
+}
+
+// TestRenderEvaluates loads the output in the real shells that are installed and reads the values back
+func TestRenderEvaluates(t *testing.T) {
+	values := map[string]string{
+		"ENTY_A": `it's "quoted"`,
+		"ENTY_B": `/Users/me/My Sites/$HOME/php.ini`,
+		"ENTY_C": `C:\Program Files\Enty\`,
+	}
+
+	shells := map[string][]string{
+		"bash":       {"bash", "-c"},
+		"zsh":        {"zsh", "-c"},
+		"fish":       {"fish", "-c"},
+		"powershell": {"pwsh", "-NoProfile", "-Command"},
+	}
+	readBack := map[string]string{
+		"bash":       `printf '%s\n' "$ENTY_A" "$ENTY_B" "$ENTY_C"`,
+		"zsh":        `printf '%s\n' "$ENTY_A" "$ENTY_B" "$ENTY_C"`,
+		"fish":       `printf '%s\n' "$ENTY_A" "$ENTY_B" "$ENTY_C"`,
+		"powershell": `$env:ENTY_A; $env:ENTY_B; $env:ENTY_C`,
+	}
+
+	for _, shell := range Shells {
+		command := shells[shell]
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		script, err := Render(shell, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(command[0], append(command[1:], script+readBack[shell])...).Output()
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n"), "\n")
+		want := []string{values["ENTY_A"], values["ENTY_B"], values["ENTY_C"]}
+		if strings.Join(lines, "|") != strings.Join(want, "|") {
+			t.Errorf("%s read back %q, want %q", shell, lines, want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package shim
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// execBinary replaces the current process with the resolved binary
+func execBinary(path, name string, args, env []string) int {
+	argv := append([]string{name}, args...)
+	err := syscall.Exec(path, argv, env)
+	fmt.Fprintf(os.Stderr, "enty: failed to exec %s: %v\n", path, err)
+	return 126
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package shim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// execBinary runs the resolved binary as a child process and mirrors its exit code,
+// since Windows has no exec(2)
+func execBinary(path, name string, args, env []string) int {
+	cmd := exec.Command(path, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "enty: failed to run %s: %v\n", path, err)
+		return 126
+	}
+	return 0
+}
@@ -0,0 +1,355 @@
+// Package shim generates and resolves per-project shims for service binaries, so shells
+// outside Enty (IDE terminals, scripts) run the version a project asks for.
+package shim
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"sort"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/project"
+)
+
+// Command is the argument that switches the Enty executable into shim mode
+const Command = "shim"
+
+// CLIName is the file name of the console CLI built from cmd/enty, without extension
+const CLIName = "enty"
+
+// Resolution describes which binary a shim resolved to and why
+type Resolution struct {
+	Service string `json:"service"`
+	Binary  string `json:"binary"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+	// Source is "manifest", "project" or "global"
+	Source string `json:"source"`
+}
+
+// Dir returns the directory shims are generated into
+func Dir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "shims"), nil
+}
+
+// Resolve finds the real executable for binary of service when run from cwd.
+// The nearest .enty.json wins, then a registered project containing cwd, then the global active version.
+func Resolve(service, binary, cwd string) (*Resolution, error) {
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
+	if err := pathsConfig.LoadConfig(); err != nil {
+		return nil, err
+	}
+
+	resolution := &Resolution{Service: service, Binary: binary}
+	serviceDir := ""
+
+	if manifestDir, ok := project.FindManifest(cwd); ok {
+		if manifest, err := project.LoadManifest(manifestDir); err == nil {
+			if requested, pinned := manifest.Services[service]; pinned {
+				version, path, ok := pathsConfig.ResolveServiceVersion(service, requested)
+				if !ok {
+					return nil, fmt.Errorf("%s %s required by %s is not installed",
+						service, requested, filepath.Join(manifestDir, project.ManifestFileName))
+				}
+				serviceDir, resolution.Version, resolution.Source = path, version, "manifest"
+			}
+		}
+	}
+
+	if serviceDir == "" {
+		if registryPath, err := config.DataPath("projects.json"); err == nil {
+			if registry, err := project.NewRegistry(registryPath); err == nil {
+				if p, ok := registry.FindByDir(cwd); ok {
+					if requested, pinned := p.Services[service]; pinned {
+						version, path, ok := pathsConfig.ResolveServiceVersion(service, requested)
+						if !ok {
+							return nil, fmt.Errorf("%s %s pinned by project %s is not installed", service, requested, p.Name)
+						}
+						serviceDir, resolution.Version, resolution.Source = path, version, "project"
+					}
+				}
+			}
+		}
+	}
+
+	if serviceDir == "" {
+		path, ok := pathsConfig.GetServicePath(service)
+		if !ok {
+			return nil, fmt.Errorf("no version of %s is configured", service)
+		}
+		serviceDir, resolution.Source = path, "global"
+	}
+
+	executable, ok := findExecutable(serviceDir, binary)
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s", binary, serviceDir)
+	}
+	resolution.Path = executable
+	return resolution, nil
+}
+
+// Generate writes a shim for every executable provided by the configured services into dir,
+// removing stale shims. exe is the Enty executable the shims call back into.
+func Generate(dir, exe string) ([]string, error) {
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
+	if err := pathsConfig.LoadConfig(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create shims directory: %w", err)
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(config.ConfigPath("paths.json")))
+	if err != nil {
+		return nil, err
+	}
+
+	// The GUI build is not attached to the console on Windows, so cmd.exe would neither
+	// wait for it nor see its output; prefer the console CLI when it is installed alongside
+	if gosruntime.GOOS == "windows" {
+		exe = consoleExecutable(exe)
+	}
+
+	// Map each binary name to the first service (alphabetically) that provides it
+	binaries := make(map[string]string)
+	for _, service := range sortedServices(pathsConfig) {
+		dirs := []string{}
+		if path, ok := pathsConfig.GetServicePath(service); ok {
+			dirs = append(dirs, path)
+		}
+		for _, path := range pathsConfig.GetServiceVersions(service) {
+			dirs = append(dirs, path)
+		}
+		for _, serviceDir := range dirs {
+			for _, binary := range listExecutables(serviceDir) {
+				if _, taken := binaries[binary]; !taken {
+					binaries[binary] = service
+				}
+			}
+		}
+	}
+
+	// Write the new shims before removing old ones, so a shim run meanwhile never goes missing
+	names := make([]string, 0, len(binaries))
+	files := make(map[string]bool, len(binaries))
+	for binary, service := range binaries {
+		file, err := writeShim(dir, exe, configDir, service, binary)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, binary)
+		files[file] = true
+	}
+	if err := removeStaleShims(dir, files); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// sortedServices returns every service that has an active path or installed versions
+func sortedServices(pathsConfig *config.PathsConfigManager) []string {
+	seen := make(map[string]bool)
+	for _, service := range pathsConfig.GetAllServices() {
+		seen[strings.ToLower(service)] = true
+	}
+	for _, service := range pathsConfig.GetInstalledServices() {
+		seen[strings.ToLower(service)] = true
+	}
+
+	sorted := make([]string, 0, len(seen))
+	for service := range seen {
+		sorted = append(sorted, service)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// consoleExecutable returns the enty CLI next to exe, or exe itself when there is none
+func consoleExecutable(exe string) string {
+	exeInfo, err := os.Stat(exe)
+	if err != nil {
+		return exe
+	}
+	name := CLIName
+	if gosruntime.GOOS == "windows" {
+		name += ".exe"
+	}
+	info, err := os.Stat(filepath.Join(filepath.Dir(exe), name))
+	// On case-insensitive file systems enty.exe may be the GUI build itself
+	if err == nil && info.Mode().IsRegular() && !os.SameFile(info, exeInfo) {
+		return filepath.Join(filepath.Dir(exe), name)
+	}
+	return exe
+}
+
+// writeShim writes a small launcher that re-enters Enty in shim mode and returns its file name
+func writeShim(dir, exe, configDir, service, binary string) (string, error) {
+	if gosruntime.GOOS == "windows" {
+		// setlocal keeps the variable from leaking into the calling cmd.exe session
+		script := fmt.Sprintf("@setlocal\r\n@echo off\r\nset \"%s=%s\"\r\n\"%s\" %s %s %s %%*\r\nexit /b %%ERRORLEVEL%%\r\n",
+			config.ConfigDirEnv, configDir, exe, Command, service, binary)
+		return binary + ".cmd", replaceFile(filepath.Join(dir, binary+".cmd"), []byte(script))
+	}
+
+	script := fmt.Sprintf("#!/bin/sh\n# Generated by Enty, do not edit\n%s=%s exec %s %s %s %s \"$@\"\n",
+		config.ConfigDirEnv, shellQuote(configDir), shellQuote(exe), Command, service, binary)
+	return binary, replaceFile(filepath.Join(dir, binary), []byte(script))
+}
+
+// replaceFile atomically replaces path with an executable file holding data, leaving it alone
+// when the content is unchanged
+func replaceFile(path string, data []byte) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".enty-shim-*")
+	if err != nil {
+		return fmt.Errorf("failed to write shim: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write shim: %w", err)
+	}
+	return nil
+}
+
+// removeStaleShims deletes files in dir other than the current shims, so uninstalled binaries disappear
+func removeStaleShims(dir string, current map[string]bool) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || current[entry.Name()] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove stale shim: %w", err)
+		}
+	}
+	return nil
+}
+
+// listExecutables returns the executable names in dir and dir/bin, without extensions on Windows
+func listExecutables(dir string) []string {
+	var names []string
+	for _, candidate := range []string{dir, filepath.Join(dir, "bin")} {
+		entries, err := os.ReadDir(candidate)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if name, ok := executableName(candidate, entry); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// findExecutable looks for binary in dir and dir/bin
+func findExecutable(dir, binary string) (string, bool) {
+	for _, candidate := range []string{dir, filepath.Join(dir, "bin")} {
+		for _, ext := range executableExtensions() {
+			path := filepath.Join(candidate, binary+ext)
+			if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func executableName(dir string, entry os.DirEntry) (string, bool) {
+	if entry.IsDir() {
+		return "", false
+	}
+
+	name := entry.Name()
+	if gosruntime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		for _, known := range executableExtensions()[1:] {
+			if ext == known {
+				return strings.TrimSuffix(name, filepath.Ext(name)), true
+			}
+		}
+		return "", false
+	}
+
+	info, err := os.Stat(filepath.Join(dir, name))
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return "", false
+	}
+	return name, true
+}
+
+func executableExtensions() []string {
+	if gosruntime.GOOS == "windows" {
+		return []string{"", ".exe", ".cmd", ".bat"}
+	}
+	return []string{""}
+}
+
+// shellQuote quotes a value for POSIX sh
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Run handles "shim <service> <binary> [args...]": it resolves the binary for the current
+// directory and executes it, returning the exit code
+func Run(args []string) int {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "usage: enty %s <service> <binary> [args...]\n", Command)
+		return 2
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "enty: %v\n", err)
+		return 1
+	}
+
+	resolution, err := Resolve(args[0], args[1], cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "enty: %v\n", err)
+		return 127
+	}
+
+	return execBinary(resolution.Path, args[1], args[2:], childEnv())
+}
+
+// childEnv is the environment of the resolved binary: the config directory the shim set
+// for Enty is removed, so it does not leak into the tool or anything it starts
+func childEnv() []string {
+	env := make([]string, 0, len(os.Environ()))
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		// Windows variable names are case-insensitive
+		if key == config.ConfigDirEnv || (gosruntime.GOOS == "windows" && strings.EqualFold(key, config.ConfigDirEnv)) {
+			continue
+		}
+		env = append(env, entry)
+	}
+	return env
+}
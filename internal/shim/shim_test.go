@@ -0,0 +1,98 @@
+package shim
+
+import (
+	"os"
+	"path/filepath"
+	gosruntime "runtime"
+	"strings"
+	"testing"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+func TestWriteShimKeepsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	file, err := writeShim(dir, "/opt/enty", "/home/me/.enty/config", "php", "php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, file)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writeShim(dir, "/opt/enty", "/home/me/.enty/config", "php", "php"); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Fatal("unchanged shim was rewritten")
+	}
+
+	data, _ := os.ReadFile(path)
+	if gosruntime.GOOS == "windows" && !strings.HasPrefix(string(data), "@setlocal\r\n") {
+		t.Fatalf("cmd shim does not start with setlocal:\n%s", data)
+	}
+}
+
+func TestRemoveStaleShims(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"php", "mysql", ".enty-shim-123"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0o755)
+	}
+	os.Mkdir(filepath.Join(dir, "keep-dir"), 0o755)
+
+	if err := removeStaleShims(dir, map[string]bool{"php": true}); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "keep-dir,php" {
+		t.Fatalf("remaining entries: %v", names)
+	}
+}
+
+func TestConsoleExecutable(t *testing.T) {
+	dir := t.TempDir()
+	gui := filepath.Join(dir, "Enty-gui")
+	os.WriteFile(gui, []byte("gui"), 0o755)
+
+	if got := consoleExecutable(gui); got != gui {
+		t.Fatalf("without a CLI: %s, want %s", got, gui)
+	}
+
+	cli := filepath.Join(dir, CLIName)
+	if gosruntime.GOOS == "windows" {
+		cli += ".exe"
+	}
+	os.WriteFile(cli, []byte("cli"), 0o755)
+	if got := consoleExecutable(gui); got != cli {
+		t.Fatalf("with a CLI: %s, want %s", got, cli)
+	}
+
+	// The GUI build is never mistaken for the CLI
+	if got := consoleExecutable(cli); got != cli {
+		t.Fatalf("CLI as exe: %s, want %s", got, cli)
+	}
+}
+
+func TestChildEnvDropsConfigDir(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, "/home/me/.enty/config")
+	t.Setenv("ENTY_SHIM_TEST", "kept")
+
+	env := strings.Join(childEnv(), "\n")
+	if strings.Contains(env, config.ConfigDirEnv+"=") {
+		t.Fatalf("%s passed to the child", config.ConfigDirEnv)
+	}
+	if !strings.Contains(env, "ENTY_SHIM_TEST=kept") {
+		t.Fatal("other variables were dropped")
+	}
+}
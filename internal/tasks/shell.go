@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// markerPrefix starts the OSC sequence a shell prints after a task with its exit code. Terminals
+// ignore unknown OSC sequences, so the marker is invisible to the user.
+const markerPrefix = "\x1b]777;enty-task;"
+
+// maxExitCodeLength bounds the text between a marker and its terminating BEL
+const maxExitCodeLength = 16
+
+// shell families, which differ in quoting and in how the exit code is read
+const (
+	familyPOSIX      = "posix"
+	familyFish       = "fish"
+	familyPowerShell = "powershell"
+	familyOther      = "other"
+)
+
+func shellFamily(shell string) string {
+	switch strings.ToLower(shell) {
+	case "sh", "bash", "zsh", "dash", "ksh", "mksh", "ash", "rbash", "git-bash":
+		return familyPOSIX
+	case "fish":
+		return familyFish
+	case "powershell", "pwsh":
+		return familyPowerShell
+	default:
+		return familyOther
+	}
+}
+
+// CommandLine returns the line to type into a shell to run task in dir. When reportsStatus is
+// true the line ends by printing a marker with the exit code, which a Scanner for token detects.
+func CommandLine(shell string, task Task, dir, token string) (line string, reportsStatus bool) {
+	family := shellFamily(shell)
+
+	command := task.Command
+	for _, arg := range task.Args {
+		command += " " + quote(family, arg)
+	}
+
+	switch family {
+	case familyPOSIX:
+		if dir != "" {
+			command = fmt.Sprintf("cd %s && %s", quote(family, dir), command)
+		}
+		// An interactive shell drops the rest of the line when a command is killed by Ctrl-C. The
+		// subshell traps SIGINT, so it exits normally with the command's status and the marker
+		// is still printed.
+		return fmt.Sprintf(`(trap : INT; %s); printf '\033]777;enty-task;%s;%%d\007' $?`, command, token), true
+	case familyFish:
+		if dir != "" {
+			command = fmt.Sprintf("pushd %s; and %s; set -l enty_status $status; popd", quote(family, dir), command)
+		} else {
+			command += "; set -l enty_status $status"
+		}
+		return fmt.Sprintf(`begin; %s; printf '\e]777;enty-task;%s;%%d\a' $enty_status; end`, command, token), true
+	case familyPowerShell:
+		if dir != "" {
+			command = fmt.Sprintf("Push-Location %s; %s; $entyStatus = $LASTEXITCODE; Pop-Location", quote(family, dir), command)
+		} else {
+			command += "; $entyStatus = $LASTEXITCODE"
+		}
+		return fmt.Sprintf(`%s; Write-Host -NoNewline "$([char]27)]777;enty-task;%s;$(if ($entyStatus -eq $null) { 0 } else { $entyStatus })$([char]7)"`, command, token), true
+	default:
+		if dir != "" && strings.EqualFold(shell, "cmd") {
+			command = fmt.Sprintf(`pushd "%s" && %s & popd`, dir, command)
+		}
+		return command, false
+	}
+}
+
+// quote quotes a value as a single word for the shell family
+func quote(family, value string) string {
+	switch family {
+	case familyFish:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+	case familyPowerShell:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case familyOther:
+		if !strings.ContainsAny(value, " \t\"'") {
+			return value
+		}
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	default:
+		return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+	}
+}
+
+// Scanner finds the exit code marker of one task run in terminal output, which may split the
+// marker across chunks
+type Scanner struct {
+	marker string
+	tail   string
+}
+
+// NewScanner returns a scanner for the marker printed by CommandLine with token
+func NewScanner(token string) *Scanner {
+	return &Scanner{marker: markerPrefix + token + ";"}
+}
+
+// Feed scans the next chunk of output and returns the exit code once the marker is complete
+func (s *Scanner) Feed(data string) (exitCode int, found bool) {
+	text := s.tail + data
+	if i := strings.Index(text, s.marker); i >= 0 {
+		rest := text[i+len(s.marker):]
+		if end := strings.IndexByte(rest, '\a'); end >= 0 {
+			code, err := strconv.Atoi(strings.TrimSpace(rest[:end]))
+			if err != nil {
+				code = 1
+			}
+			s.tail = ""
+			return code, true
+		}
+		// Keep the incomplete marker for the next chunk, unless it is not followed by an exit code
+		if len(rest) <= maxExitCodeLength {
+			s.tail = text[i:]
+		} else {
+			s.tail = ""
+		}
+		return 0, false
+	}
+
+	// Keep enough to match a marker that starts at the end of this chunk
+	if keep := len(s.marker) - 1; len(text) > keep {
+		text = text[len(text)-keep:]
+	}
+	s.tail = text
+	return 0, false
+}
@@ -0,0 +1,132 @@
+package tasks
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommandLine(t *testing.T) {
+	task := Task{Command: "php artisan", Args: []string{"migrate", "--path=db/it's here", `say "hi"`, `C:\tmp\`}}
+	dir := `/srv/my site's`
+
+	cases := []struct {
+		shell         string
+		dir           string
+		want          string
+		reportsStatus bool
+	}{
+		{"bash", dir,
+			`(trap : INT; cd '/srv/my site'\''s' && php artisan 'migrate' '--path=db/it'\''s here' 'say "hi"' 'C:\tmp\'); ` +
+				`printf '\033]777;enty-task;tok;%d\007' $?`, true},
+		{"zsh", "",
+			`(trap : INT; php artisan 'migrate' '--path=db/it'\''s here' 'say "hi"' 'C:\tmp\'); ` +
+				`printf '\033]777;enty-task;tok;%d\007' $?`, true},
+		{"fish", dir,
+			`begin; pushd '/srv/my site\'s'; and php artisan 'migrate' '--path=db/it\'s here' 'say "hi"' 'C:\\tmp\\'; ` +
+				`set -l enty_status $status; popd; printf '\e]777;enty-task;tok;%d\a' $enty_status; end`, true},
+		{"pwsh", dir,
+			`Push-Location '/srv/my site''s'; php artisan 'migrate' '--path=db/it''s here' 'say "hi"' 'C:\tmp\'; ` +
+				`$entyStatus = $LASTEXITCODE; Pop-Location; ` +
+				`Write-Host -NoNewline "$([char]27)]777;enty-task;tok;$(if ($entyStatus -eq $null) { 0 } else { $entyStatus })$([char]7)"`, true},
+		{"cmd", `C:\My Sites`,
+			`pushd "C:\My Sites" && php artisan migrate "--path=db/it's here" "say \"hi\"" C:\tmp\ & popd`, false},
+		{"nu", dir,
+			`php artisan migrate "--path=db/it's here" "say \"hi\"" C:\tmp\`, false},
+	}
+	for _, tc := range cases {
+		line, reportsStatus := CommandLine(tc.shell, task, tc.dir, "tok")
+		if line != tc.want || reportsStatus != tc.reportsStatus {
+			t.Errorf("%s:\ngot  %s (%v)\nwant %s (%v)", tc.shell, line, reportsStatus, tc.want, tc.reportsStatus)
+		}
+	}
+}
+
+// TestCommandLineRunsInPOSIXShells runs the generated line and checks the arguments, directory
+// and exit code marker
+func TestCommandLineRunsInPOSIXShells(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), `my site's "dir"`)
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, shell := range []string{"sh", "bash", "zsh"} {
+		path, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		task := Task{Command: `printf '%s|' "$PWD"`, Args: []string{"it's", `"quoted" $HOME`, `back\slash`}}
+		line, _ := CommandLine(shell, task, dir, "tok")
+		out, err := exec.Command(path, "-c", line+"; exit 3").Output()
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+			t.Fatalf("%s: %v", shell, err)
+		}
+
+		want := dir + `|it's|"quoted" $HOME|back\slash|` + markerPrefix + "tok;0\a"
+		if string(out) != want {
+			t.Errorf("%s output %q, want %q", shell, out, want)
+		}
+
+		// A failing command reports its own exit code
+		line, _ = CommandLine(shell, Task{Command: "exit 7"}, "", "tok")
+		out, _ = exec.Command(path, "-c", line).Output()
+		if code, found := NewScanner("tok").Feed(string(out)); !found || code != 7 {
+			t.Errorf("%s: exit code %d, %v from %q", shell, code, found, out)
+		}
+	}
+}
+
+func TestScannerSplitMarker(t *testing.T) {
+	output := "running migrations\r\n" + markerPrefix + "tok;42\a$ "
+
+	// Every split of the output into two chunks finds the marker exactly once
+	for i := 0; i <= len(output); i++ {
+		scanner := NewScanner("tok")
+		code, found := scanner.Feed(output[:i])
+		if !found {
+			code, found = scanner.Feed(output[i:])
+		} else if _, again := scanner.Feed(output[i:]); again {
+			t.Fatalf("split at %d: marker found twice", i)
+		}
+		if !found || code != 42 {
+			t.Fatalf("split at %d: %d, %v", i, code, found)
+		}
+	}
+
+	// One byte at a time
+	scanner := NewScanner("tok")
+	found := false
+	for i := 0; i < len(output) && !found; i++ {
+		var code int
+		if code, found = scanner.Feed(output[i : i+1]); found && code != 42 {
+			t.Fatalf("byte by byte: exit code %d", code)
+		}
+	}
+	if !found {
+		t.Fatal("byte by byte: marker not found")
+	}
+}
+
+func TestScannerIgnoresOtherOutput(t *testing.T) {
+	cases := map[string][]string{
+		"other token":        {markerPrefix + "other;0\a"},
+		"unterminated":       {markerPrefix + "tok;0", strings.Repeat("x", 100), "\a"},
+		"prefix only":        {markerPrefix[:5], "tok;0\a"},
+		"marker text echoed": {`printf '\033]777;enty-task;tok;%d\007' $?`},
+	}
+	for name, chunks := range cases {
+		scanner := NewScanner("tok")
+		for _, chunk := range chunks {
+			if code, found := scanner.Feed(chunk); found {
+				t.Errorf("%s: found exit code %d", name, code)
+			}
+		}
+	}
+
+	// A malformed exit code counts as a failure
+	if code, found := NewScanner("tok").Feed(markerPrefix + "tok;oops\a"); !found || code != 1 {
+		t.Fatalf("malformed exit code: %d, %v", code, found)
+	}
+}
@@ -0,0 +1,229 @@
+// Package tasks stores named commands, such as migrations or test runs, defined globally or per
+// project, together with the status of their last run.
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run states of a task
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+	// StatusUnknown is recorded when the shell cannot report exit codes, such as cmd.exe
+	StatusUnknown = "unknown"
+)
+
+// Task is a named command
+type Task struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Cwd is relative to the project root for project tasks
+	Cwd string `json:"cwd,omitempty"`
+	// Services are started before the task if Enty manages them, e.g. "mysql"
+	Services []string `json:"services,omitempty"`
+}
+
+// Run is the outcome of the last run of a task
+type Run struct {
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   *int      `json:"exitCode,omitempty"`
+	SessionID  string    `json:"sessionID,omitempty"`
+}
+
+// Info is a task with its scope and last run
+type Info struct {
+	Task
+	// ProjectID is empty for global tasks
+	ProjectID string `json:"projectID,omitempty"`
+	LastRun   *Run   `json:"lastRun,omitempty"`
+}
+
+// storeFile is the JSON layout of the tasks file
+type storeFile struct {
+	Global   []Task            `json:"global"`
+	Projects map[string][]Task `json:"projects"`
+	Runs     map[string]Run    `json:"runs"`
+}
+
+// Store keeps tasks in a JSON file
+type Store struct {
+	path  string
+	data  storeFile
+	mutex sync.RWMutex
+}
+
+// NewStore loads the tasks stored at path, starting empty if the file does not exist
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read tasks: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			return nil, fmt.Errorf("failed to parse tasks: %w", err)
+		}
+	}
+	if s.data.Projects == nil {
+		s.data.Projects = make(map[string][]Task)
+	}
+	if s.data.Runs == nil {
+		s.data.Runs = make(map[string]Run)
+	}
+	return s, nil
+}
+
+// List returns the tasks of a project followed by the global tasks; an empty projectID lists only global tasks
+func (s *Store) List(projectID string) []Info {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var infos []Info
+	if projectID != "" {
+		infos = append(infos, s.infos(projectID, s.data.Projects[projectID])...)
+	}
+	return append(infos, s.infos("", s.data.Global)...)
+}
+
+// Get finds a task by name, preferring the project's own task over a global one
+func (s *Store) Get(projectID, name string) (*Info, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if projectID != "" {
+		if i := indexOf(s.data.Projects[projectID], name); i >= 0 {
+			info := s.infos(projectID, s.data.Projects[projectID][i:i+1])[0]
+			return &info, nil
+		}
+	}
+	if i := indexOf(s.data.Global, name); i >= 0 {
+		info := s.infos("", s.data.Global[i:i+1])[0]
+		return &info, nil
+	}
+	return nil, fmt.Errorf("task %s not found", name)
+}
+
+// Save adds a task to a project, or globally when projectID is empty, replacing one with the same name
+func (s *Store) Save(projectID string, task Task) error {
+	task.Name = strings.TrimSpace(task.Name)
+	task.Command = strings.TrimSpace(task.Command)
+	if task.Name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if task.Command == "" {
+		return fmt.Errorf("task command is required")
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := s.list(projectID)
+	if i := indexOf(list, task.Name); i >= 0 {
+		list[i] = task
+	} else {
+		list = append(list, task)
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	s.setList(projectID, list)
+	return s.save()
+}
+
+// Delete removes a task and its run history
+func (s *Store) Delete(projectID, name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := s.list(projectID)
+	i := indexOf(list, name)
+	if i < 0 {
+		return fmt.Errorf("task %s not found", name)
+	}
+	delete(s.data.Runs, runKey(projectID, list[i].Name))
+	s.setList(projectID, append(list[:i:i], list[i+1:]...))
+	return s.save()
+}
+
+// RecordRun stores the latest run of a task
+func (s *Store) RecordRun(projectID, name string, run Run) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.data.Runs[runKey(projectID, name)] = run
+	return s.save()
+}
+
+// infos attaches scope and last runs to tasks (assumes lock is held)
+func (s *Store) infos(projectID string, list []Task) []Info {
+	infos := make([]Info, 0, len(list))
+	for _, task := range list {
+		info := Info{Task: task, ProjectID: projectID}
+		if run, ok := s.data.Runs[runKey(projectID, task.Name)]; ok {
+			info.LastRun = &run
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func (s *Store) list(projectID string) []Task {
+	if projectID == "" {
+		return s.data.Global
+	}
+	return s.data.Projects[projectID]
+}
+
+func (s *Store) setList(projectID string, list []Task) {
+	switch {
+	case projectID == "":
+		s.data.Global = list
+	case len(list) == 0:
+		delete(s.data.Projects, projectID)
+	default:
+		s.data.Projects[projectID] = list
+	}
+}
+
+// save writes the tasks to disk (assumes lock is held)
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tasks: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create tasks directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write tasks: %w", err)
+	}
+	return nil
+}
+
+func indexOf(list []Task, name string) int {
+	for i, task := range list {
+		if strings.EqualFold(task.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// runKey identifies a task in the run history; task names are case-insensitive
+func runKey(projectID, name string) string {
+	if projectID == "" {
+		return "global/" + strings.ToLower(name)
+	}
+	return "project/" + projectID + "/" + strings.ToLower(name)
+}
@@ -0,0 +1,374 @@
+// Package termbridge exposes terminal sessions over WebSocket so browsers and remote editors
+// can attach to them. Any number of viewers may attach to the same session; each receives the
+// output and may send input and resize requests.
+package termbridge
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// PathPrefix is the URL path sessions are attached under: PathPrefix + sessionID
+	PathPrefix = "/terminal/"
+
+	// viewerBuffer is the number of pending messages after which a slow viewer is disconnected
+	viewerBuffer = 256
+	writeTimeout = 10 * time.Second
+)
+
+// Session is the part of a terminal session the bridge needs
+type Session interface {
+	Write(input string) error
+	Resize(cols, rows int) error
+	Subscribe(onData func(data string), onExit func(message string)) (unsubscribe func())
+}
+
+// Attacher is implemented by sessions that keep scrollback. Viewers attaching to such a
+// session first receive the buffered output, then the live stream without gaps or duplicates.
+type Attacher interface {
+	Attach(onData func(data string), onExit func(message string)) (offset int64, unsubscribe func())
+}
+
+// Lookup finds a session by ID
+type Lookup func(sessionID string) (Session, error)
+
+// Message is exchanged as a JSON text frame in both directions.
+// Clients send "input" and "resize"; the server sends "output" and "exit".
+type Message struct {
+	Type    string `json:"type"`
+	Data    string `json:"data,omitempty"`
+	Cols    int    `json:"cols,omitempty"`
+	Rows    int    `json:"rows,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ServerOptions configures a bridge server
+type ServerOptions struct {
+	// Addr is the listen address, e.g. "127.0.0.1:7681"
+	Addr string
+	// Token authenticates clients; a random token is generated when empty
+	Token  string
+	Lookup Lookup
+}
+
+// Server accepts WebSocket connections and attaches them to terminal sessions
+type Server struct {
+	opts       ServerOptions
+	upgrader   websocket.Upgrader
+	mutex      sync.Mutex
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// NewServer creates a bridge server. Use Handler directly to serve it from an existing
+// http.Server or httptest, or Start to listen on opts.Addr.
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.Lookup == nil {
+		return nil, errors.New("termbridge: a session lookup is required")
+	}
+	if opts.Token == "" {
+		buf := make([]byte, 24)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate bridge token: %w", err)
+		}
+		opts.Token = hex.EncodeToString(buf)
+	}
+
+	return &Server{
+		opts: opts,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  4096,
+			WriteBufferSize: 4096,
+			CheckOrigin:     checkOrigin,
+		},
+	}, nil
+}
+
+// Token returns the token clients must present
+func (s *Server) Token() string {
+	return s.opts.Token
+}
+
+// Start listens on the configured address and serves connections in the background
+func (s *Server) Start() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.listener != nil {
+		return fmt.Errorf("terminal bridge is already running")
+	}
+
+	listener, err := net.Listen("tcp", s.opts.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.opts.Addr, err)
+	}
+
+	s.listener = listener
+	s.httpServer = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Terminal bridge stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop closes the listener and all attached connections
+func (s *Server) Stop() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.httpServer == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		// Hijacked WebSocket connections are not tracked by Shutdown
+		err = s.httpServer.Close()
+	}
+	s.httpServer = nil
+	s.listener = nil
+	return err
+}
+
+// Addr returns the address the server listens on, or an empty string when stopped
+func (s *Server) Addr() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+// Handler returns the HTTP handler serving PathPrefix + sessionID
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathPrefix, s.serveSession)
+	return mux
+}
+
+func (s *Server) serveSession(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := strings.TrimPrefix(r.URL.Path, PathPrefix)
+	if sessionID == "" {
+		http.Error(w, "missing session ID", http.StatusNotFound)
+		return
+	}
+	session, err := s.opts.Lookup(sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		return
+	}
+
+	newViewer(conn, session).run()
+}
+
+// authorized accepts the token as a bearer header or, for browsers, a "token" query parameter
+func (s *Server) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) == 1
+}
+
+// checkOrigin accepts clients without an Origin (editors, scripts), pages served by this host,
+// pages on loopback and the Wails webview, so a remote site cannot drive a terminal even when
+// it has obtained the token
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if u.Scheme == "wails" || strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// viewer is one WebSocket connection attached to a session
+type viewer struct {
+	conn     *websocket.Conn
+	session  Session
+	outgoing chan Message
+	done     chan struct{}
+	once     sync.Once
+	// closeCode is sent in the close frame; it is set before done is closed
+	closeCode int
+	closeText string
+}
+
+func newViewer(conn *websocket.Conn, session Session) *viewer {
+	return &viewer{
+		conn:     conn,
+		session:  session,
+		outgoing: make(chan Message, viewerBuffer),
+		done:     make(chan struct{}),
+	}
+}
+
+// run attaches to the session and blocks until the connection or session ends
+func (v *viewer) run() {
+	onData := func(data string) { v.send(Message{Type: "output", Data: data}) }
+	onExit := func(message string) {
+		v.send(Message{Type: "exit", Message: message})
+		v.close()
+	}
+
+	var unsubscribe func()
+	if attacher, ok := v.session.(Attacher); ok {
+		_, unsubscribe = attacher.Attach(onData, onExit)
+	} else {
+		unsubscribe = v.session.Subscribe(onData, onExit)
+	}
+	defer unsubscribe()
+
+	go v.writeLoop()
+	v.readLoop()
+	v.close()
+}
+
+// send queues a message, disconnecting viewers that cannot keep up instead of blocking the session
+func (v *viewer) send(msg Message) {
+	select {
+	case <-v.done:
+	case v.outgoing <- msg:
+	default:
+		v.closeWith(websocket.CloseTryAgainLater, "viewer too slow")
+	}
+}
+
+func (v *viewer) close() {
+	v.closeWith(websocket.CloseNormalClosure, "")
+}
+
+// closeWith ends the connection; the first call decides the close frame sent to the client
+func (v *viewer) closeWith(code int, text string) {
+	v.once.Do(func() {
+		v.closeCode, v.closeText = code, text
+		close(v.done)
+	})
+}
+
+func (v *viewer) writeLoop() {
+	defer v.conn.Close()
+	for {
+		// Closing takes priority, so a slow viewer is not sent its whole backlog first
+		select {
+		case <-v.done:
+			v.finish()
+			return
+		default:
+		}
+
+		select {
+		case msg := <-v.outgoing:
+			v.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := v.conn.WriteJSON(msg); err != nil {
+				v.closeWith(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-v.done:
+			v.finish()
+			return
+		}
+	}
+}
+
+// finish sends the close frame, first flushing what is already queued, such as the exit
+// message, unless the viewer is being dropped for falling behind
+func (v *viewer) finish() {
+	if v.closeCode == websocket.CloseNormalClosure {
+		for flushed := false; !flushed; {
+			select {
+			case msg := <-v.outgoing:
+				v.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if v.conn.WriteJSON(msg) != nil {
+					return
+				}
+			default:
+				flushed = true
+			}
+		}
+	}
+	v.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(v.closeCode, v.closeText), time.Now().Add(writeTimeout))
+}
+
+func (v *viewer) readLoop() {
+	for {
+		messageType, data, err := v.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		// Binary frames carry raw keyboard input
+		if messageType == websocket.BinaryMessage {
+			if err := v.session.Write(string(data)); err != nil {
+				return
+			}
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			v.send(Message{Type: "error", Message: "invalid message"})
+			continue
+		}
+
+		switch msg.Type {
+		case "input":
+			if err := v.session.Write(msg.Data); err != nil {
+				v.send(Message{Type: "error", Message: err.Error()})
+			}
+		case "resize":
+			if msg.Cols <= 0 || msg.Rows <= 0 {
+				v.send(Message{Type: "error", Message: "invalid size"})
+				continue
+			}
+			if err := v.session.Resize(msg.Cols, msg.Rows); err != nil {
+				v.send(Message{Type: "error", Message: err.Error()})
+			}
+		default:
+			v.send(Message{Type: "error", Message: "unknown message type: " + msg.Type})
+		}
+	}
+}
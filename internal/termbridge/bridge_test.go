@@ -0,0 +1,237 @@
+package termbridge
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/utils"
+	"github.com/gorilla/websocket"
+)
+
+const testToken = "secret"
+
+// startBridge serves a bridge over httptest with one running session backed by a FakePTY
+func startBridge(t *testing.T) (string, *utils.FakePTY) {
+	t.Helper()
+	factory := utils.NewFakePTYFactory()
+	manager := utils.NewTerminalManagerWithPTY(factory)
+	t.Cleanup(manager.CleanupAll)
+
+	session, err := manager.CreateSession(utils.CreateSessionOptions{SessionID: "one", TerminalType: "bash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatal(err)
+	}
+	session.StartReadLoop(nil, nil)
+
+	bridge, err := NewServer(ServerOptions{
+		Token: testToken,
+		Lookup: func(sessionID string) (Session, error) {
+			return manager.GetSession(sessionID)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(bridge.Handler())
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http") + PathPrefix, factory.Last()
+}
+
+func dial(t *testing.T, url string, header http.Header) *websocket.Conn {
+	t.Helper()
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		t.Fatalf("dial %s: %v (status %d)", url, err, status)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestRejectsBadToken(t *testing.T) {
+	base, _ := startBridge(t)
+
+	for _, tc := range []struct {
+		url    string
+		header http.Header
+	}{
+		{base + "one", nil},
+		{base + "one?token=wrong", nil},
+		{base + "one", bearer("wrong")},
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(tc.url, tc.header)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s %v: err %v, want 401", tc.url, tc.header, err)
+		}
+	}
+
+	// Unknown sessions are reported after authentication
+	_, resp, err := websocket.DefaultDialer.Dial(base+"missing", bearer(testToken))
+	if err == nil || resp == nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing session: err %v, want 404", err)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	base, _ := startBridge(t)
+
+	for _, origin := range []string{"http://evil.example", "https://localhost.evil.example", "null"} {
+		_, resp, err := websocket.DefaultDialer.Dial(base+"one?token="+testToken, http.Header{"Origin": {origin}})
+		if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("origin %s: err %v, want 403", origin, err)
+		}
+	}
+	for _, origin := range []string{"http://localhost:5173", "http://127.0.0.1:8080", "http://[::1]", "http://wails.localhost", "wails://wails"} {
+		dial(t, base+"one?token="+testToken, http.Header{"Origin": {origin}})
+	}
+}
+
+func TestInputOutputAndResize(t *testing.T) {
+	base, pty := startBridge(t)
+	conn := dial(t, base+"one", bearer(testToken))
+
+	if err := conn.WriteJSON(Message{Type: "input", Data: "ls\r"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("pwd\r")); err != nil {
+		t.Fatal(err)
+	}
+	if !pty.WaitForInput("ls\rpwd\r", 5*time.Second) {
+		t.Fatalf("pty input %q", pty.Input())
+	}
+
+	if err := pty.Emit("hello\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	var msg Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != "output" || msg.Data != "hello\r\n" {
+		t.Fatalf("got %+v, want output", msg)
+	}
+
+	if err := conn.WriteJSON(Message{Type: "resize", Cols: 120, Rows: 40}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for cols, rows := pty.Size(); cols != 120 || rows != 40; cols, rows = pty.Size() {
+		if time.Now().After(deadline) {
+			t.Fatalf("pty size %dx%d, want 120x40", cols, rows)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := conn.WriteJSON(Message{Type: "resize"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.ReadJSON(&msg); err != nil || msg.Type != "error" {
+		t.Fatalf("invalid resize: %+v, %v", msg, err)
+	}
+}
+
+func TestReplaysScrollbackOnAttach(t *testing.T) {
+	base, pty := startBridge(t)
+	first := dial(t, base+"one", bearer(testToken))
+
+	pty.Emit("before")
+	var msg Message
+	if err := first.ReadJSON(&msg); err != nil || msg.Data != "before" {
+		t.Fatalf("first viewer: %+v, %v", msg, err)
+	}
+
+	second := dial(t, base+"one?token="+testToken, nil)
+	if err := second.ReadJSON(&msg); err != nil || msg.Type != "output" || msg.Data != "before" {
+		t.Fatalf("replay: %+v, %v", msg, err)
+	}
+}
+
+func TestExitClosesConnection(t *testing.T) {
+	base, pty := startBridge(t)
+	conn := dial(t, base+"one", bearer(testToken))
+
+	pty.Emit("bye\r\n")
+	pty.Exit(3)
+
+	var messages []Message
+	for {
+		var msg Message
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Fatalf("read error %v, want a normal close", err)
+			}
+			break
+		}
+		messages = append(messages, msg)
+	}
+
+	if len(messages) != 2 || messages[0].Data != "bye\r\n" || messages[1].Type != "exit" {
+		t.Fatalf("messages before close: %+v", messages)
+	}
+	if !strings.Contains(messages[1].Message, "3") {
+		t.Fatalf("exit message %q does not mention the exit code", messages[1].Message)
+	}
+}
+
+// stubSession hands its callbacks to the test so it can produce output faster than a viewer reads
+type stubSession struct {
+	subscribed chan func(data string)
+}
+
+func (s *stubSession) Write(string) error    { return nil }
+func (s *stubSession) Resize(int, int) error { return nil }
+
+func (s *stubSession) Subscribe(onData func(data string), onExit func(message string)) func() {
+	s.subscribed <- onData
+	return func() {}
+}
+
+func TestSlowViewerGetsCloseFrame(t *testing.T) {
+	session := &stubSession{subscribed: make(chan func(data string), 1)}
+	bridge, err := NewServer(ServerOptions{
+		Token:  testToken,
+		Lookup: func(string) (Session, error) { return session, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(bridge.Handler())
+	defer server.Close()
+
+	conn := dial(t, "ws"+strings.TrimPrefix(server.URL, "http")+PathPrefix+"one", bearer(testToken))
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+	onData := <-session.subscribed
+
+	// The client does not read, so socket buffers fill, the writer blocks and the queue overflows
+	chunk := strings.Repeat("x", 64*1024)
+	for i := 0; i < viewerBuffer*2; i++ {
+		onData(chunk)
+	}
+
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+				t.Fatalf("read error %v, want close %d", err, websocket.CloseTryAgainLater)
+			}
+			return
+		}
+	}
+}
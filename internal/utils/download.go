@@ -6,8 +6,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 func (u *utils) DownloadFile(name string, filename string, url string, buf int32) (err error) {
@@ -50,25 +48,25 @@ func (u *utils) DownloadFile(name string, filename string, url string, buf int32
 		return fmt.Errorf("bad status: %s", resp.Status)
 	}
 
-	runtime.EventsEmit(u.ctx, "start-download-file", name, totalBytes)
+	u.bus.Emit("start-download-file", name, totalBytes)
 
-	runtime.EventsOnce(u.ctx, "cancel-download-file", func(optionalData ...interface{}) {
+	unsubscribe := u.bus.Subscribe("cancel-download-file", func(optionalData ...interface{}) {
 		// Cancel all downloads if no filename provided
 		// Or cancel specific download if filename matches
 		if len(optionalData) == 0 {
 			cancel()
-			runtime.EventsEmit(u.ctx, "download-cancelled", name)
+			u.bus.Emit("download-cancelled", name)
 		} else if filename, ok := optionalData[0].(string); ok && filename == name {
 			cancel()
-			runtime.EventsEmit(u.ctx, "download-cancelled", name)
+			u.bus.Emit("download-cancelled", name)
 		}
 	})
-	defer runtime.EventsOffAll(u.ctx)
+	defer unsubscribe()
 
 	for {
 		select {
 		case <-ctx.Done():
-			runtime.EventsEmit(u.ctx, "download-cancelled", name)
+			u.bus.Emit("download-cancelled", name)
 			os.Remove(fmt.Sprintf("%s/%s", PATH_TEMP, name)) // Clean up partial file
 			return ctx.Err()
 		default:
@@ -81,11 +79,11 @@ func (u *utils) DownloadFile(name string, filename string, url string, buf int32
 				}
 				downloadedBytes += int64(n)
 
-				runtime.EventsEmit(u.ctx, "download-file", name, totalBytes, downloadedBytes)
+				u.bus.Emit("download-file", name, totalBytes, downloadedBytes)
 			}
 
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
-				runtime.EventsEmit(u.ctx, "finish-download-file", name)
+				u.bus.Emit("finish-download-file", name)
 				return nil
 			}
 			if err != nil {
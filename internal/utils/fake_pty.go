@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakePIDBase is above the largest Linux pid, so fake shells never match a real process
+const fakePIDBase = 1 << 22
+
+// FakePTYFactory starts in-memory terminals that run no process, for deterministic tests of
+// sessions and the manager
+type FakePTYFactory struct {
+	// StartError, when set, is returned by Start instead of a terminal
+	StartError error
+
+	mutex   sync.Mutex
+	started []*FakePTY
+}
+
+// NewFakePTYFactory creates a factory of in-memory terminals
+func NewFakePTYFactory() *FakePTYFactory {
+	return &FakePTYFactory{}
+}
+
+// Start returns a new FakePTY recording cmd
+func (f *FakePTYFactory) Start(cmd PTYCommand) (PTY, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.StartError != nil {
+		return nil, f.StartError
+	}
+	p := &FakePTY{
+		Command: cmd,
+		pid:     fakePIDBase + len(f.started) + 1,
+		changed: make(chan struct{}),
+		exited:  make(chan struct{}),
+	}
+	f.started = append(f.started, p)
+	return p, nil
+}
+
+// Started returns the terminals started so far, oldest first
+func (f *FakePTYFactory) Started() []*FakePTY {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*FakePTY(nil), f.started...)
+}
+
+// Last returns the most recently started terminal, or nil
+func (f *FakePTYFactory) Last() *FakePTY {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.started) == 0 {
+		return nil
+	}
+	return f.started[len(f.started)-1]
+}
+
+// FakePTY is an in-memory terminal. Output queued with Emit is returned by Read, input written by
+// the session is collected for Input, and Exit or Kill end the fake shell.
+type FakePTY struct {
+	// Command is the shell the session asked to start
+	Command PTYCommand
+
+	pid      int
+	mutex    sync.Mutex
+	output   []byte
+	input    strings.Builder
+	cols     int
+	rows     int
+	closed   bool
+	blocked  bool
+	exitCode int
+	signal   string
+	// changed is closed and replaced whenever output, input or the state changes
+	changed chan struct{}
+	exited  chan struct{}
+}
+
+// notifyLocked wakes everything waiting for a change (assumes lock is held)
+func (p *FakePTY) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *FakePTY) hasExitedLocked() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// Read returns queued output, blocking until there is some. Like a PTY whose shell has gone, it
+// returns io.EOF once the shell has exited and its output has been read, or after Close.
+func (p *FakePTY) Read(buf []byte) (int, error) {
+	for {
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			return 0, io.EOF
+		}
+		if len(p.output) > 0 {
+			n := copy(buf, p.output)
+			p.output = p.output[n:]
+			p.mutex.Unlock()
+			return n, nil
+		}
+		if p.hasExitedLocked() {
+			p.mutex.Unlock()
+			return 0, io.EOF
+		}
+		changed := p.changed
+		p.mutex.Unlock()
+
+		<-changed
+	}
+}
+
+// Write records input sent to the shell, waiting while writes are blocked
+func (p *FakePTY) Write(data []byte) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.blocked && !p.closed {
+		changed := p.changed
+		p.mutex.Unlock()
+		<-changed
+		p.mutex.Lock()
+	}
+	if p.closed {
+		return 0, fmt.Errorf("terminal is closed")
+	}
+	p.input.Write(data)
+	p.notifyLocked()
+	return len(data), nil
+}
+
+// BlockWrites makes Write wait until it is called with false or the terminal is closed, like a
+// shell that stopped reading its input
+func (p *FakePTY) BlockWrites(block bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.blocked = block
+	p.notifyLocked()
+}
+
+// Close closes the terminal, ending pending reads
+func (p *FakePTY) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.closed {
+		p.closed = true
+		p.notifyLocked()
+	}
+	return nil
+}
+
+// Resize records the terminal size
+func (p *FakePTY) Resize(cols, rows int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return fmt.Errorf("terminal is closed")
+	}
+	p.cols, p.rows = cols, rows
+	p.notifyLocked()
+	return nil
+}
+
+// Pid returns a process ID that belongs to no real process
+func (p *FakePTY) Pid() int {
+	return p.pid
+}
+
+// Kill ends the fake shell as SIGKILL would
+func (p *FakePTY) Kill() error {
+	p.exit(-1, "killed")
+	return nil
+}
+
+// Wait blocks until Exit or Kill is called
+func (p *FakePTY) Wait() (int, string) {
+	<-p.exited
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.exitCode, p.signal
+}
+
+// Emit queues output as if the shell had printed it
+func (p *FakePTY) Emit(data string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed || p.hasExitedLocked() {
+		return fmt.Errorf("terminal is closed")
+	}
+	p.output = append(p.output, data...)
+	p.notifyLocked()
+	return nil
+}
+
+// Exit ends the fake shell with code; output queued before still reaches the session
+func (p *FakePTY) Exit(code int) {
+	p.exit(code, "")
+}
+
+func (p *FakePTY) exit(code int, signal string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.hasExitedLocked() {
+		return
+	}
+	p.exitCode, p.signal = code, signal
+	close(p.exited)
+	p.notifyLocked()
+}
+
+// Input returns everything written to the terminal so far
+func (p *FakePTY) Input() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.input.String()
+}
+
+// Size returns the size set by the last Resize
+func (p *FakePTY) Size() (cols, rows int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.cols, p.rows
+}
+
+// IsClosed reports whether the session has closed the terminal
+func (p *FakePTY) IsClosed() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.closed
+}
+
+// WaitForInput waits until the input contains text, returning false after timeout
+func (p *FakePTY) WaitForInput(text string, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		p.mutex.Lock()
+		found := strings.Contains(p.input.String(), text)
+		changed := p.changed
+		p.mutex.Unlock()
+
+		if found {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			return false
+		}
+	}
+}
@@ -0,0 +1,17 @@
+//go:build linux
+
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// processCwd returns the current working directory of a process
+func processCwd(pid int) (string, bool) {
+	cwd, err := os.Readlink("/proc/" + strconv.Itoa(pid) + "/cwd")
+	if err != nil {
+		return "", false
+	}
+	return cwd, true
+}
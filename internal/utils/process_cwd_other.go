@@ -0,0 +1,8 @@
+//go:build !linux
+
+package utils
+
+// processCwd is not supported on this platform; sessions report the directory they started in
+func processCwd(pid int) (string, bool) {
+	return "", false
+}
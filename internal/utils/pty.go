@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"syscall"
+
+	"github.com/aymanbagabas/go-pty"
+)
+
+// PTYCommand describes the shell to start in a pseudo terminal
+type PTYCommand struct {
+	Path string
+	Args []string
+	// Dir is the working directory; empty uses the current directory
+	Dir string
+	Env []string
+}
+
+// PTY is a pseudo terminal with a running shell attached
+type PTY interface {
+	io.ReadWriteCloser
+	Resize(cols, rows int) error
+	// Pid returns the process ID of the shell
+	Pid() int
+	// Kill terminates the shell
+	Kill() error
+	// Wait blocks until the shell exits. exitCode is -1 when it is unknown, and signal names the
+	// signal that terminated the shell, if any.
+	Wait() (exitCode int, signal string)
+}
+
+// PTYFactory starts shells in pseudo terminals
+type PTYFactory interface {
+	Start(cmd PTYCommand) (PTY, error)
+}
+
+// SystemPTYFactory starts shells in operating system pseudo terminals: a Unix PTY or ConPTY on Windows
+type SystemPTYFactory struct{}
+
+// Start creates a pseudo terminal and starts cmd in it
+func (SystemPTYFactory) Start(command PTYCommand) (PTY, error) {
+	p, err := pty.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PTY: %w", err)
+	}
+
+	cmd := p.Command(command.Path, command.Args...)
+	cmd.Dir = command.Dir
+	cmd.Env = command.Env
+	if err := cmd.Start(); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	// Only the shell keeps the terminal side open, so reads end once it and its children exit
+	releaseSlave(p)
+	return &systemPTY{Pty: p, cmd: cmd}, nil
+}
+
+// systemPTY is a go-pty terminal together with its shell process
+type systemPTY struct {
+	pty.Pty
+	cmd *pty.Cmd
+}
+
+func (p *systemPTY) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *systemPTY) Kill() error {
+	return p.cmd.Process.Kill()
+}
+
+func (p *systemPTY) Wait() (int, string) {
+	p.cmd.Wait()
+
+	state := p.cmd.ProcessState
+	if state == nil {
+		return -1, ""
+	}
+	if waitStatus, ok := state.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+		return state.ExitCode(), waitStatus.Signal().String()
+	}
+	return state.ExitCode(), ""
+}
+
+// Control runs fn with the file descriptor of the terminal, which only Unix PTYs have
+func (p *systemPTY) Control(fn func(fd uintptr)) error {
+	unixPty, ok := p.Pty.(pty.UnixPty)
+	if !ok {
+		return fmt.Errorf("terminal has no file descriptor")
+	}
+	return unixPty.Control(fn)
+}
+
+// releaseSlave closes the parent's copy of the terminal side of a Unix PTY; the shell holds its own
+func releaseSlave(p pty.Pty) {
+	if unixPty, ok := p.(pty.UnixPty); ok {
+		unixPty.Slave().Close()
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+// DefaultScrollbackSize is the scrollback kept per session when no size is configured
+const DefaultScrollbackSize = 256 * 1024
+
+// scrollback is a fixed-size ring buffer holding the most recent output of a session
+type scrollback struct {
+	buf   []byte
+	start int
+	size  int
+	// total counts every byte ever written, so callers can tell which output a snapshot covers
+	total int64
+}
+
+func newScrollback(capacity int) *scrollback {
+	if capacity <= 0 {
+		capacity = DefaultScrollbackSize
+	}
+	return &scrollback{buf: make([]byte, capacity)}
+}
+
+// Write appends p, discarding the oldest bytes once the buffer is full
+func (s *scrollback) Write(p []byte) {
+	s.total += int64(len(p))
+	capacity := len(s.buf)
+
+	if len(p) >= capacity {
+		copy(s.buf, p[len(p)-capacity:])
+		s.start, s.size = 0, capacity
+		return
+	}
+
+	end := (s.start + s.size) % capacity
+	n := copy(s.buf[end:], p)
+	copy(s.buf, p[n:])
+
+	s.size += len(p)
+	if s.size > capacity {
+		s.start = (s.start + s.size - capacity) % capacity
+		s.size = capacity
+	}
+}
+
+// Bytes returns a copy of the buffered output, oldest first. When old output has been
+// discarded, a UTF-8 sequence cut in half at the start is dropped.
+func (s *scrollback) Bytes() []byte {
+	out := make([]byte, s.size)
+	n := copy(out, s.buf[s.start:min(s.start+s.size, len(s.buf))])
+	copy(out[n:], s.buf[:s.size-n])
+
+	if s.total > int64(s.size) {
+		for i := 0; i < 3 && len(out) > 0 && out[0]&0xC0 == 0x80; i++ {
+			out = out[1:]
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// restoredMarker follows the replayed output of a restored session. It leaves the alternate screen
+// and resets colors in case the session was saved while a full-screen program was running.
+const restoredMarker = "\x1b[?1049l\x1b[0m\r\n\x1b[2m[Restored from the previous run]\x1b[0m\r\n"
+
+// SavedSession is the definition of a session written on exit so it can be recreated with the same ID
+type SavedSession struct {
+	CreateSessionOptions
+	// Scrollback is the most recent output of the session
+	Scrollback string    `json:"scrollback,omitempty"`
+	SavedAt    time.Time `json:"savedAt"`
+}
+
+// SetSessionTitle changes the name shown for a session
+func (tm *TerminalManager) SetSessionTitle(sessionID, title string) error {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	session, exists := tm.sessions[sessionID]
+	if !exists {
+		return fmt.Errorf("session %s not found", sessionID)
+	}
+	session.mutex.Lock()
+	session.title = title
+	session.mutex.Unlock()
+	tm.saveState()
+	return nil
+}
+
+// SaveSessions writes the definitions of the active sessions to path with up to scrollbackBytes of
+// their recent output. Without active sessions any previous file is removed.
+func (tm *TerminalManager) SaveSessions(path string, scrollbackBytes int) error {
+	tm.mutex.RLock()
+	saved := make([]SavedSession, 0, len(tm.sessions))
+	for _, session := range tm.sessions {
+		saved = append(saved, session.saved(scrollbackBytes))
+	}
+	tm.mutex.RUnlock()
+
+	return WriteSavedSessions(path, saved)
+}
+
+// WriteSavedSessions replaces the saved sessions at path, removing the file when sessions is empty
+func WriteSavedSessions(path string, sessions []SavedSession) error {
+	if len(sessions) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].SessionID < sessions[j].SessionID })
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Sessions may carry environment variables, so the file is private
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadSavedSessions reads the sessions written by SaveSessions; a missing file means none
+func LoadSavedSessions(path string) ([]SavedSession, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []SavedSession
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ReplayOptions returns the options that recreate a saved session, with its output placed in the
+// scrollback. The startup command is kept only when runStartupCommand is set.
+func (s SavedSession) ReplayOptions(runStartupCommand bool) CreateSessionOptions {
+	opts := s.CreateSessionOptions
+	if !runStartupCommand {
+		opts.InitialCommand = ""
+	}
+	if opts.Dir != "" {
+		if stat, err := os.Stat(opts.Dir); err != nil || !stat.IsDir() {
+			opts.Dir = ""
+		}
+	}
+	if s.Scrollback != "" {
+		opts.Replay = s.Scrollback + restoredMarker
+	}
+	return opts
+}
+
+// saved captures the definition of the session, using the shell's current directory when known.
+// The timeout policy is saved as it is now, including changes made after the session started.
+func (ts *TerminalSession) saved(scrollbackBytes int) SavedSession {
+	info := ts.Info()
+
+	ts.mutex.RLock()
+	timeout := ts.timeout
+	opts := CreateSessionOptions{
+		SessionID:      ts.sessionID,
+		TerminalType:   ts.terminalType,
+		Title:          ts.title,
+		ServicePins:    ts.servicePins,
+		Services:       ts.services,
+		Env:            ts.env,
+		Executable:     ts.executable,
+		Args:           ts.args,
+		Dir:            info.Cwd,
+		InitialCommand: ts.initialInput,
+		Timeout:        &timeout,
+	}
+	ts.mutex.RUnlock()
+
+	ts.viewersMutex.RLock()
+	opts.ScrollbackSize = len(ts.scrollback.buf)
+	ts.viewersMutex.RUnlock()
+
+	s := SavedSession{CreateSessionOptions: opts, SavedAt: time.Now()}
+	if scrollbackBytes > 0 {
+		data, _ := ts.Transcript()
+		s.Scrollback = recentOutput(data, scrollbackBytes)
+	}
+	return s
+}
+
+// recentOutput returns the last limit bytes of output, starting at a line boundary when possible
+func recentOutput(data string, limit int) string {
+	if len(data) <= limit {
+		return data
+	}
+	data = data[len(data)-limit:]
+	if i := strings.IndexByte(data, '\n'); i >= 0 {
+		return data[i+1:]
+	}
+	for len(data) > 0 && !utf8.RuneStart(data[0]) {
+		data = data[1:]
+	}
+	return data
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+func TestSavedSessionKeepsTimeoutAndScrollbackSize(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	t.Cleanup(tm.CleanupAll)
+	timeout := config.TerminalTimeout{Minutes: 30, WarningSeconds: 60, Action: config.TimeoutActionDetach}
+	session, _ := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one", ScrollbackSize: 4096, Timeout: &timeout})
+
+	// A policy changed after start is the one saved
+	timeout.InputOnly = true
+	session.SetTimeoutPolicy(timeout)
+
+	path := filepath.Join(t.TempDir(), "saved.json")
+	if err := tm.SaveSessions(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := LoadSavedSessions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("saved %+v", saved)
+	}
+
+	opts := saved[0].ReplayOptions(false)
+	if opts.ScrollbackSize != 4096 {
+		t.Errorf("scrollback size %d, want 4096", opts.ScrollbackSize)
+	}
+	if opts.Timeout == nil || !reflect.DeepEqual(*opts.Timeout, timeout) {
+		t.Errorf("timeout %+v, want %+v", opts.Timeout, timeout)
+	}
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// SessionInfo describes a terminal session and, once it has ended, how its shell exited
+type SessionInfo struct {
+	SessionID    string    `json:"sessionID"`
+	TerminalType string    `json:"terminalType"`
+	Title        string    `json:"title,omitempty"`
+	LastActivity time.Time `json:"lastActivity"`
+	// Shell is the name of the shell executable, e.g. "zsh" or "pwsh"
+	Shell     string     `json:"shell,omitempty"`
+	PID       int        `json:"pid,omitempty"`
+	Cwd       string     `json:"cwd,omitempty"`
+	Running   bool       `json:"running"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	ExitedAt  *time.Time `json:"exitedAt,omitempty"`
+	// ExitCode is unset while running and when the process was terminated by a signal
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Signal   string `json:"signal,omitempty"`
+}
+
+// sessionState is the file written for other processes such as the CLI
+type sessionState struct {
+	PID      int           `json:"pid"`
+	Sessions []SessionInfo `json:"sessions"`
+}
+
+// SetStatePath makes the manager mirror its sessions to path so the CLI can list them
+func (tm *TerminalManager) SetStatePath(path string) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.statePath = path
+	tm.saveState()
+}
+
+// Sessions returns information about all active sessions, ordered by ID
+func (tm *TerminalManager) Sessions() []SessionInfo {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return tm.sessionInfos()
+}
+
+// sessionInfos collects session information (internal, assumes lock is held)
+func (tm *TerminalManager) sessionInfos() []SessionInfo {
+	infos := make([]SessionInfo, 0, len(tm.sessions))
+	for _, session := range tm.sessions {
+		infos = append(infos, session.Info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].SessionID < infos[j].SessionID })
+	return infos
+}
+
+// saveState writes the session list to the state file (internal, assumes lock is held)
+func (tm *TerminalManager) saveState() {
+	if tm.statePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(sessionState{PID: os.Getpid(), Sessions: tm.sessionInfos()}, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(tm.statePath, data, 0o600)
+}
+
+// LoadSessionState reads the sessions written by a running Enty instance and the pid of that instance
+func LoadSessionState(path string) (pid int, sessions []SessionInfo, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, nil, err
+	}
+	return state.PID, state.Sessions, nil
+}
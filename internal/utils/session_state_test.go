@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionStateRecordsShellPID(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	tm.SetStatePath(path)
+
+	session, err := tm.CreateSession(CreateSessionOptions{SessionID: "one", TerminalType: "bash"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer tm.CleanupAll()
+
+	_, sessions, err := LoadSessionState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].PID != factory.Last().Pid() || !sessions[0].Running {
+		t.Fatalf("saved sessions %+v, want pid %d", sessions, factory.Last().Pid())
+	}
+}
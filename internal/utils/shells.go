@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Shell is an interactive shell installed on this machine
+type Shell struct {
+	// Name is the terminal type used to start the shell, e.g. "zsh" or "pwsh"
+	Name string   `json:"name"`
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+}
+
+// DiscoverShells returns the shells installed on this machine, the user's default shell first
+func DiscoverShells() []Shell {
+	var shells []Shell
+	seen := make(map[string]bool)
+	for _, shell := range platformShells() {
+		if shell.Name == "" || seen[shell.Name] {
+			continue
+		}
+		if stat, err := os.Stat(shell.Path); err != nil || stat.IsDir() {
+			continue
+		}
+		seen[shell.Name] = true
+		shells = append(shells, shell)
+	}
+	return shells
+}
+
+// FindShell returns the installed shell with the given name
+func FindShell(name string) (Shell, bool) {
+	for _, shell := range DiscoverShells() {
+		if strings.EqualFold(shell.Name, name) {
+			return shell, true
+		}
+	}
+	return Shell{}, false
+}
+
+// shellName derives the terminal type of a shell from its executable path
+func shellName(path string) string {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+}
+
+// lookPath resolves an executable on PATH, returning an empty string when it is missing
+func lookPath(file string) string {
+	path, err := exec.LookPath(file)
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// shellsFile lists the valid login shells of the system
+const shellsFile = "/etc/shells"
+
+// platformShells returns $SHELL followed by the entries of /etc/shells
+func platformShells() []Shell {
+	var shells []Shell
+	if path := os.Getenv("SHELL"); path != "" {
+		shells = append(shells, Shell{Name: shellName(path), Path: path})
+	}
+
+	file, err := os.Open(shellsFile)
+	if err != nil {
+		return append(shells, Shell{Name: "sh", Path: "/bin/sh"})
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := shellName(line)
+		// Accounts use these to disable logins; they are not interactive shells
+		if name == "nologin" || name == "false" || name == "true" {
+			continue
+		}
+		shells = append(shells, Shell{Name: name, Path: line})
+	}
+	return shells
+}
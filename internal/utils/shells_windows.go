@@ -0,0 +1,70 @@
+//go:build windows
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// platformShells returns PowerShell, cmd and the shells found in the registry or their default install locations
+func platformShells() []Shell {
+	systemRoot := os.Getenv("SystemRoot")
+	if systemRoot == "" {
+		systemRoot = `C:\Windows`
+	}
+	system32 := filepath.Join(systemRoot, "System32")
+
+	shells := []Shell{
+		{Name: "powershell", Path: filepath.Join(system32, "WindowsPowerShell", "v1.0", "powershell.exe")},
+	}
+
+	pwsh := registryAppPath("pwsh.exe")
+	if pwsh == "" {
+		pwsh = lookPath("pwsh.exe")
+	}
+	if pwsh == "" {
+		pwsh = filepath.Join(os.Getenv("ProgramFiles"), "PowerShell", "7", "pwsh.exe")
+	}
+	shells = append(shells, Shell{Name: "pwsh", Path: pwsh})
+
+	cmd := os.Getenv("ComSpec")
+	if cmd == "" {
+		cmd = filepath.Join(system32, "cmd.exe")
+	}
+	shells = append(shells, Shell{Name: "cmd", Path: cmd})
+
+	gitRoot := registryString(registry.LOCAL_MACHINE, `SOFTWARE\GitForWindows`, "InstallPath")
+	if gitRoot == "" {
+		gitRoot = filepath.Join(os.Getenv("ProgramFiles"), "Git")
+	}
+	shells = append(shells, Shell{Name: "git-bash", Path: filepath.Join(gitRoot, "bin", "bash.exe"), Args: []string{"--login", "-i"}})
+
+	shells = append(shells, Shell{Name: "wsl", Path: filepath.Join(system32, "wsl.exe")})
+	return shells
+}
+
+// registryAppPath resolves an executable registered under App Paths for the user or the machine
+func registryAppPath(executable string) string {
+	key := `SOFTWARE\Microsoft\Windows\CurrentVersion\App Paths\` + executable
+	if path := registryString(registry.CURRENT_USER, key, ""); path != "" {
+		return path
+	}
+	return registryString(registry.LOCAL_MACHINE, key, "")
+}
+
+func registryString(root registry.Key, path, name string) string {
+	key, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+
+	value, _, err := key.GetStringValue(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
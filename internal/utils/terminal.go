@@ -2,59 +2,133 @@ package utils
 
 import (
 	"fmt"
-	"io"
+	"log"
 	"os"
 	gosruntime "runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/JadlionHD/Enty/internal/config"
-	"github.com/aymanbagabas/go-pty"
 )
 
 // TerminalSession manages a single PTY terminal session
 type TerminalSession struct {
-	pty          pty.Pty
-	cmd          *pty.Cmd
+	pty          PTY
+	ptyFactory   PTYFactory
 	mutex        sync.RWMutex // Changed to RWMutex for better performance
 	isRunning    bool
 	sessionID    string
 	terminalType string
+	title        string
+	servicePins  map[string]string
+	services     []string
+	env          map[string]string
+	executable   string
+	args         []string
+	dir          string
+	initialInput string
+	shellPath    string
+	pid          int
+	startedAt    time.Time
+	exit         *exitStatus
+	outputDone   chan struct{} // Closed when the PTY has no more output
+	stopOnce     sync.Once
 	// serviceName removed: PATH is now managed globally based on config
 	lastActivity time.Time
+	timeout      config.TerminalTimeout
 	timeoutTimer *time.Timer
+	warningTimer *time.Timer
 	onTimeout    func(sessionID string)
+	onWarning    func(sessionID string, remaining time.Duration)
+	onDetach     func(sessionID string)
+	onStart      func(sessionID string)
 	readBuffer   chan []byte   // Buffered channel for efficient data streaming
 	writeBuffer  chan []byte   // Buffered channel for write operations
 	stopChannel  chan struct{} // Channel for graceful shutdown
+	cols         int
+	rows         int
+	recorder     OutputRecorder
+	stopRecorder func()
+	// interrupted is closed and replaced each time the foreground command is interrupted
+	interrupted chan struct{}
+	// viewersMutex guards the viewers and the scrollback, so attaching is atomic with output
+	viewers      map[int]terminalViewer
+	nextViewerID int
+	scrollback   *scrollback
+	// exitMessageSent is set once the viewers have been told the session exited
+	exitMessageSent *string
+	viewersMutex    sync.RWMutex
 }
 
 // NewTerminalSession creates a new terminal session
 func NewTerminalSession() *TerminalSession {
-	return &TerminalSession{
-		isRunning: false,
-	}
+	return NewTerminalSessionWithOptions(TerminalSessionOptions{})
 }
 
 // TerminalSessionOptions holds options for creating a terminal session
 type TerminalSessionOptions struct {
 	SessionID    string
 	TerminalType string
+	// Title is the name shown for the session, e.g. its tab label
+	Title string
+	// ServicePins selects per-project service versions for the session PATH
+	ServicePins map[string]string
+	// Services limits the services placed on PATH; empty means all configured services
+	Services []string
+	Env      map[string]string
+	// Executable and Args override the shell chosen from TerminalType
+	Executable string
+	Args       []string
+	// Dir is the working directory of the shell; empty uses the current directory
+	Dir string
+	// InitialCommand is typed into the shell once it has started
+	InitialCommand string
+	// Timeout is the inactivity policy; nil uses the default 60 minute kill
+	Timeout *config.TerminalTimeout
+	// ScrollbackSize is the number of output bytes kept for reattaching; 0 uses DefaultScrollbackSize
+	ScrollbackSize int
+	// Replay is placed in the scrollback before the shell starts, e.g. the output of a restored session
+	Replay string
 }
 
 // NewTerminalSessionWithOptions creates a new terminal session with the specified options
 // This unified function replaces NewTerminalSessionWithID and NewTerminalSessionWithService
 func NewTerminalSessionWithOptions(opts TerminalSessionOptions) *TerminalSession {
-	return &TerminalSession{
+	timeout := config.DefaultSettings().Terminal.Timeout
+	if opts.Timeout != nil {
+		timeout = *opts.Timeout
+	}
+	session := &TerminalSession{
 		isRunning:    false,
 		sessionID:    opts.SessionID,
 		terminalType: opts.TerminalType,
+		title:        opts.Title,
+		servicePins:  opts.ServicePins,
+		services:     opts.Services,
+		env:          opts.Env,
+		executable:   opts.Executable,
+		args:         opts.Args,
+		dir:          opts.Dir,
+		initialInput: opts.InitialCommand,
+		timeout:      timeout,
+		scrollback:   newScrollback(opts.ScrollbackSize),
 		lastActivity: time.Now(),
 		readBuffer:   make(chan []byte, 100), // Buffered channel for performance
 		writeBuffer:  make(chan []byte, 50),  // Buffered channel for writes
 		stopChannel:  make(chan struct{}, 1), // Channel for clean shutdown
+		outputDone:   make(chan struct{}),
+	}
+	if opts.Replay != "" {
+		session.scrollback.Write([]byte(opts.Replay))
 	}
+	return session
+}
+
+// SetPTYFactory replaces the backend that starts the shell; it must be called before Start
+func (ts *TerminalSession) SetPTYFactory(factory PTYFactory) {
+	ts.ptyFactory = factory
 }
 
 // SetTimeoutCallback sets the callback function for session timeout
@@ -62,8 +136,48 @@ func (ts *TerminalSession) SetTimeoutCallback(callback func(sessionID string)) {
 	ts.onTimeout = callback
 }
 
+// SetTimeoutWarningCallback sets the callback invoked WarningSeconds before the session times out
+func (ts *TerminalSession) SetTimeoutWarningCallback(callback func(sessionID string, remaining time.Duration)) {
+	ts.onWarning = callback
+}
+
+// SetDetachCallback sets the callback invoked when the detach timeout action leaves the session running
+func (ts *TerminalSession) SetDetachCallback(callback func(sessionID string)) {
+	ts.onDetach = callback
+}
+
+// SetTimeoutPolicy replaces the inactivity policy and restarts the timeout from now
+func (ts *TerminalSession) SetTimeoutPolicy(timeout config.TerminalTimeout) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	ts.timeout = timeout
+	if ts.isRunning {
+		ts.startTimeoutTimer()
+	}
+}
+
+// TimeoutPolicy returns the inactivity policy of the session
+func (ts *TerminalSession) TimeoutPolicy() config.TerminalTimeout {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return ts.timeout
+}
+
 // Start initializes and starts a PTY terminal session
 func (ts *TerminalSession) Start() error {
+	if err := ts.start(); err != nil {
+		return err
+	}
+	// Called without the lock, as the manager reads the session to record its pid
+	if ts.onStart != nil {
+		ts.onStart(ts.sessionID)
+	}
+	return nil
+}
+
+// start launches the shell in a PTY and the goroutines that serve it
+func (ts *TerminalSession) start() error {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
 
@@ -73,56 +187,68 @@ func (ts *TerminalSession) Start() error {
 
 	// Determine shell based on terminal type or platform default
 	shell, args := ts.getShellCommand()
+	ts.shellPath = shell
 
-	// Create PTY with optimized settings
-	ptyInstance, err := pty.New()
-	if err != nil {
-		return fmt.Errorf("failed to create PTY: %w", err)
+	factory := ts.ptyFactory
+	if factory == nil {
+		factory = SystemPTYFactory{}
 	}
 
-	// Create command using PTY's Command method with optimizations
-	cmd := ptyInstance.Command(shell, args...)
-
 	// Set up isolated environment if service is specified
 	// This does NOT tamper with global environment - only affects this specific session
 	// Always build environment based on config, ignore serviceName
-	cmd.Env = BuildIsolatedEnvForService(ts.terminalType, "")
-
-	// Start the command
-	err = cmd.Start()
+	ptyInstance, err := factory.Start(PTYCommand{
+		Path: shell,
+		Args: args,
+		Dir:  ts.dir,
+		Env: BuildIsolatedEnv(IsolatedEnvOptions{
+			ShellType:   ts.terminalType,
+			ServicePins: ts.servicePins,
+			Services:    ts.services,
+			Env:         ts.env,
+		}),
+	})
 	if err != nil {
-		ptyInstance.Close()
-		return fmt.Errorf("failed to start command: %w", err)
+		return err
 	}
 
 	ts.pty = ptyInstance
-	ts.cmd = cmd
 	ts.isRunning = true
-	ts.lastActivity = time.Now()
+	ts.pid = ptyInstance.Pid()
+	ts.startedAt = time.Now()
+	ts.lastActivity = ts.startedAt
 
-	// Start timeout timer (60 minutes)
+	// The PTY buffers input until the shell reads it, so the command runs once the prompt is up
+	if ts.initialInput != "" {
+		ts.writeBuffer <- []byte(ts.initialInput + "\r")
+	}
+
+	// Start the inactivity timeout
 	ts.startTimeoutTimer()
 
 	// Start optimized I/O goroutines
 	go ts.writeHandler() // Handle buffered writes
 	go ts.readHandler()  // Handle buffered reads
+	go ts.waitProcess(ptyInstance)
 
 	return nil
 }
 
 // Write sends input to the terminal (optimized with buffering)
 func (ts *TerminalSession) Write(input string) error {
-	ts.mutex.RLock()
-	if !ts.isRunning || ts.pty == nil {
-		ts.mutex.RUnlock()
+	// The PTY is taken under the lock, as Stop or the shell exiting may release it concurrently
+	ts.mutex.Lock()
+	pty := ts.pty
+	if !ts.isRunning || pty == nil {
+		ts.mutex.Unlock()
 		return fmt.Errorf("terminal is not running")
 	}
-	ts.mutex.RUnlock()
-
 	// Update last activity time
-	ts.mutex.Lock()
 	ts.lastActivity = time.Now()
 	ts.resetTimeoutTimer()
+	if strings.ContainsRune(input, '\x03') {
+		ts.notifyInterruptLocked()
+	}
 	ts.mutex.Unlock()
 
 	// Use buffered write for better performance
@@ -131,13 +257,40 @@ func (ts *TerminalSession) Write(input string) error {
 	case ts.writeBuffer <- data:
 		return nil
 	default:
-		// If buffer is full, write directly (fallback)
-		_, err := ts.pty.Write(data)
+		// If buffer is full, write directly (fallback); a closed PTY returns an error
+		_, err := pty.Write(data)
 		return err
 	}
 }
 
-// Stop closes the PTY terminal session
+// Interrupted returns a channel that is closed the next time the foreground command is
+// interrupted, by a Ctrl-C written to the session or a call to NotifyInterrupt
+func (ts *TerminalSession) Interrupted() <-chan struct{} {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if ts.interrupted == nil {
+		ts.interrupted = make(chan struct{})
+	}
+	return ts.interrupted
+}
+
+// NotifyInterrupt reports an interrupt that did not go through the terminal, such as a signal
+// sent to the foreground process group
+func (ts *TerminalSession) NotifyInterrupt() {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.notifyInterruptLocked()
+}
+
+// notifyInterruptLocked wakes those waiting on Interrupted (assumes lock is held)
+func (ts *TerminalSession) notifyInterruptLocked() {
+	if ts.interrupted != nil {
+		close(ts.interrupted)
+		ts.interrupted = nil
+	}
+}
+
+// Stop closes the PTY terminal session, killing the shell
 func (ts *TerminalSession) Stop() error {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
@@ -146,32 +299,27 @@ func (ts *TerminalSession) Stop() error {
 		return nil
 	}
 
+	if ts.pty != nil {
+		ts.pty.Kill()
+	}
+	ts.shutdownLocked()
+	return nil
+}
+
+// shutdownLocked releases the PTY and signals the goroutines to stop (assumes lock is held)
+func (ts *TerminalSession) shutdownLocked() {
 	// Signal shutdown to goroutines
-	close(ts.stopChannel)
+	ts.stopOnce.Do(func() { close(ts.stopChannel) })
 
-	// Stop timeout timer
-	if ts.timeoutTimer != nil {
-		ts.timeoutTimer.Stop()
-		ts.timeoutTimer = nil
-	}
+	// Stop timeout timers
+	ts.stopTimeoutTimers()
 
 	if ts.pty != nil {
 		ts.pty.Close()
 	}
 
-	if ts.cmd != nil && ts.cmd.Process != nil {
-		ts.cmd.Process.Kill()
-	}
-
 	ts.isRunning = false
 	ts.pty = nil
-	ts.cmd = nil
-
-	// Close channels
-	close(ts.readBuffer)
-	close(ts.writeBuffer)
-
-	return nil
 }
 
 // IsRunning returns the terminal status (optimized with RLock)
@@ -192,7 +340,19 @@ func (ts *TerminalSession) Resize(cols, rows int) error {
 		return fmt.Errorf("terminal is not running")
 	}
 
-	return pty.Resize(cols, rows)
+	if err := pty.Resize(cols, rows); err != nil {
+		return err
+	}
+
+	ts.mutex.Lock()
+	ts.cols, ts.rows = cols, rows
+	recorder := ts.recorder
+	ts.mutex.Unlock()
+
+	if recorder != nil {
+		recorder.Resize(cols, rows)
+	}
+	return nil
 }
 
 // Read reads data from the terminal (blocking)
@@ -238,17 +398,9 @@ func (ts *TerminalSession) readHandler() {
 		default:
 			n, err := ts.Read(buf)
 			if err != nil {
-				if err == io.EOF {
-					return
-				}
-				ts.mutex.RLock()
-				running := ts.isRunning
-				ts.mutex.RUnlock()
-
-				if !running {
-					return
-				}
-				continue
+				// EOF, or EIO on Unix once the shell has closed the terminal
+				close(ts.outputDone)
+				return
 			}
 
 			if n > 0 {
@@ -258,10 +410,12 @@ func (ts *TerminalSession) readHandler() {
 
 				select {
 				case ts.readBuffer <- data:
-					// Update activity time
+					// Update activity time unless only input counts as activity
 					ts.mutex.Lock()
-					ts.lastActivity = time.Now()
-					ts.resetTimeoutTimer()
+					if !ts.timeout.InputOnly {
+						ts.lastActivity = time.Now()
+						ts.resetTimeoutTimer()
+					}
 					ts.mutex.Unlock()
 				case <-ts.stopChannel:
 					return
@@ -276,10 +430,11 @@ func GetPlatformShell() (string, []string) {
 	switch gosruntime.GOOS {
 	case "windows":
 		return "powershell.exe", []string{}
-	case "darwin", "linux":
-		return "/bin/bash", []string{}
 	default:
-		// Fallback to sh for unknown platforms
+		// The user's login shell, then the first installed shell, then sh
+		if shells := DiscoverShells(); len(shells) > 0 {
+			return shells[0].Path, shells[0].Args
+		}
 		return "/bin/sh", []string{}
 	}
 }
@@ -292,22 +447,41 @@ type TerminalExitCallback func(message string)
 
 // StartReadLoop starts a goroutine to continuously read from the terminal and call the provided callback
 func (ts *TerminalSession) StartReadLoop(onData TerminalReadCallback, onExit TerminalExitCallback) {
+	dispatch := func(data []byte) {
+		viewers := ts.recordOutput(data)
+		if onData != nil {
+			onData(string(data))
+		}
+		for _, viewer := range viewers {
+			if viewer.onData != nil {
+				viewer.onData(string(data))
+			}
+		}
+	}
+
 	go func() {
 		for {
 			select {
 			case data := <-ts.readBuffer:
-				if onData != nil && len(data) > 0 {
-					onData(string(data))
+				if len(data) > 0 {
+					dispatch(data)
 				}
 			case <-ts.stopChannel:
-				// Terminal process ended, clean up
-				ts.mutex.Lock()
-				ts.isRunning = false
-				ts.mutex.Unlock()
+				// Deliver output that arrived before the process ended
+				for drained := false; !drained; {
+					select {
+					case data := <-ts.readBuffer:
+						dispatch(data)
+					default:
+						drained = true
+					}
+				}
 
+				message := ts.exitMessage()
 				if onExit != nil {
-					onExit("Terminal session ended")
+					onExit(message)
 				}
+				ts.broadcastExit(message)
 				return
 			}
 		}
@@ -316,20 +490,45 @@ func (ts *TerminalSession) StartReadLoop(onData TerminalReadCallback, onExit Ter
 
 // getShellCommand returns the appropriate shell command based on terminal type
 func (ts *TerminalSession) getShellCommand() (string, []string) {
+	if ts.executable != "" {
+		return ts.executable, ts.args
+	}
+	if shell, ok := FindShell(ts.terminalType); ok {
+		return shell.Path, shell.Args
+	}
+
 	switch ts.terminalType {
 	case "cmd":
 		return "cmd.exe", []string{}
 	case "powershell":
 		return "powershell.exe", []string{}
-	case "bash":
-		return "/bin/bash", []string{}
 	default:
 		// Use platform default
 		return GetPlatformShell()
 	}
 }
 
-func BuildIsolatedEnvForService(shellType, serviceName string) (envSlice []string) {
+// IsolatedEnvOptions controls how the isolated environment of a session is built
+type IsolatedEnvOptions struct {
+	ShellType   string
+	ServiceName string
+	// ServicePins selects a specific installed version per service instead of the global servicePaths
+	ServicePins map[string]string
+	// Services limits PATH to these services when ServiceName is empty; empty means all services
+	Services []string
+	// Env holds extra variables set on top of the inherited environment
+	Env map[string]string
+}
+
+func BuildIsolatedEnvForService(shellType, serviceName string) []string {
+	return BuildIsolatedEnv(IsolatedEnvOptions{
+		ShellType:   shellType,
+		ServiceName: serviceName,
+	})
+}
+
+// BuildIsolatedEnv builds the environment of a session, placing the selected service paths on PATH
+func BuildIsolatedEnv(opts IsolatedEnvOptions) (envSlice []string) {
 	defer func() {
 		if r := recover(); r != nil {
 			envSlice = os.Environ()
@@ -343,26 +542,45 @@ func BuildIsolatedEnvForService(shellType, serviceName string) (envSlice []strin
 		}
 	}
 
-	pathsConfig := config.NewPathsConfigManager("config/paths.json")
+	pathsConfig := config.NewPathsConfigManager(config.ConfigPath("paths.json"))
 	if err := pathsConfig.LoadConfig(); err != nil {
-		return os.Environ()
+		// Without service paths the extra variables still apply
+		for key, value := range opts.Env {
+			baseEnv[key] = value
+		}
+		for key, value := range baseEnv {
+			envSlice = append(envSlice, key+"="+value)
+		}
+		return envSlice
 	}
 
 	var pathComponents []string
 
-	if serviceName == "" {
-		// Prepend all valid service paths
-		for _, servicePath := range pathsConfig.GetAllServicePaths() {
-			if stat, err := os.Stat(servicePath); err == nil && stat.IsDir() {
-				pathComponents = append(pathComponents, servicePath)
+	var serviceNames []string
+	if opts.ServiceName == "" {
+		// Prepend all valid service paths, including services only available as pinned versions
+		seen := make(map[string]bool)
+		for name := range pathsConfig.GetAllServicePaths() {
+			seen[strings.ToLower(name)] = true
+		}
+		for name := range opts.ServicePins {
+			seen[strings.ToLower(name)] = true
+		}
+		for name := range seen {
+			if len(opts.Services) == 0 || containsFold(opts.Services, name) {
+				serviceNames = append(serviceNames, name)
 			}
 		}
+		sort.Strings(serviceNames)
 	} else {
 		// Add only the requested service path if valid
-		if servicePath, exists := pathsConfig.GetServicePath(serviceName); exists {
-			if stat, err := os.Stat(servicePath); err == nil && stat.IsDir() {
-				pathComponents = append(pathComponents, servicePath)
-			}
+		serviceNames = []string{strings.ToLower(opts.ServiceName)}
+	}
+
+	for _, name := range serviceNames {
+		servicePath := resolveServicePath(pathsConfig, name, opts.ServicePins)
+		if stat, err := os.Stat(servicePath); servicePath != "" && err == nil && stat.IsDir() {
+			pathComponents = append(pathComponents, servicePath)
 		}
 	}
 
@@ -387,10 +605,16 @@ func BuildIsolatedEnvForService(shellType, serviceName string) (envSlice []strin
 		sep = ";"
 	}
 	pathStr := strings.Join(pathComponents, sep)
-	if pathStr == "" {
+	if pathStr == "" && len(opts.Env) == 0 {
 		return os.Environ()
 	}
-	baseEnv["PATH"] = pathStr
+	if pathStr != "" {
+		baseEnv["PATH"] = pathStr
+	}
+
+	for key, value := range opts.Env {
+		baseEnv[key] = value
+	}
 
 	for key, value := range baseEnv {
 		envSlice = append(envSlice, key+"="+value)
@@ -398,17 +622,85 @@ func BuildIsolatedEnvForService(shellType, serviceName string) (envSlice []strin
 	return
 }
 
-// startTimeoutTimer starts or resets the 60-minute inactivity timeout timer
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveServicePath returns the pinned version path of a service, or its global path when not pinned
+func resolveServicePath(pathsConfig *config.PathsConfigManager, serviceName string, pins map[string]string) string {
+	for pinnedName, version := range pins {
+		if !strings.EqualFold(pinnedName, serviceName) {
+			continue
+		}
+		_, path, ok := pathsConfig.ResolveServiceVersion(serviceName, version)
+		if !ok {
+			log.Printf("Pinned %s version %s is not installed", serviceName, version)
+			return ""
+		}
+		return path
+	}
+
+	path, _ := pathsConfig.GetServicePath(serviceName)
+	return path
+}
+
+// timeoutUnit is the length of a TerminalTimeout minute; tests shorten it
+var timeoutUnit = time.Minute
+
+// startTimeoutTimer starts or resets the inactivity timeout, scheduling the warning before it
 func (ts *TerminalSession) startTimeoutTimer() {
-	if ts.timeoutTimer != nil {
-		ts.timeoutTimer.Stop()
+	ts.stopTimeoutTimers()
+
+	// Output read just before the session stopped must not start a new timeout
+	if ts.timeout.Minutes <= 0 || !ts.isRunning {
+		return
+	}
+	timeout := time.Duration(ts.timeout.Minutes) * timeoutUnit
+	sessionID := ts.sessionID
+
+	// The lock is held here and taken by the callbacks, so each timer is assigned before it is read
+	if warning := time.Duration(ts.timeout.WarningSeconds) * time.Second; warning > 0 && warning < timeout {
+		var warningTimer *time.Timer
+		warningTimer = time.AfterFunc(timeout-warning, func() {
+			ts.mutex.Lock()
+			stale := ts.warningTimer != warningTimer
+			ts.mutex.Unlock()
+			// Activity reset the timeout while this timer was firing
+			if stale {
+				return
+			}
+			if ts.onWarning != nil {
+				ts.onWarning(sessionID, warning)
+			}
+		})
+		ts.warningTimer = warningTimer
 	}
 
-	ts.timeoutTimer = time.AfterFunc(60*time.Minute, func() {
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
 		ts.mutex.Lock()
-		sessionID := ts.sessionID
+		if ts.timeoutTimer != timer {
+			// Activity reset the timeout while this timer was firing
+			ts.mutex.Unlock()
+			return
+		}
+		detach := ts.timeout.Action == config.TimeoutActionDetach
+		ts.timeoutTimer, ts.warningTimer = nil, nil
 		ts.mutex.Unlock()
 
+		// Detached sessions keep running; the timeout starts again with the next activity
+		if detach {
+			if ts.onDetach != nil {
+				ts.onDetach(sessionID)
+			}
+			return
+		}
+
 		// Stop the session
 		ts.Stop()
 
@@ -417,6 +709,19 @@ func (ts *TerminalSession) startTimeoutTimer() {
 			ts.onTimeout(sessionID)
 		}
 	})
+	ts.timeoutTimer = timer
+}
+
+// stopTimeoutTimers cancels the pending timeout and warning (assumes lock is held)
+func (ts *TerminalSession) stopTimeoutTimers() {
+	if ts.timeoutTimer != nil {
+		ts.timeoutTimer.Stop()
+		ts.timeoutTimer = nil
+	}
+	if ts.warningTimer != nil {
+		ts.warningTimer.Stop()
+		ts.warningTimer = nil
+	}
 }
 
 // resetTimeoutTimer resets the timeout timer on activity
@@ -435,20 +740,44 @@ type TerminalManager struct {
 	sessions    map[string]*TerminalSession
 	sessionPool map[string][]*TerminalSession // Pool for reusing sessions
 	mutex       sync.RWMutex
+	statePath   string
+	ended       []*TerminalSession // Recently removed sessions, oldest first
+	ptyFactory  PTYFactory
 }
 
-// NewTerminalManager creates a new terminal manager
+// NewTerminalManager creates a new terminal manager whose sessions run in system pseudo terminals
 func NewTerminalManager() *TerminalManager {
+	return NewTerminalManagerWithPTY(SystemPTYFactory{})
+}
+
+// NewTerminalManagerWithPTY creates a terminal manager whose sessions are started by factory,
+// e.g. a FakePTYFactory in tests
+func NewTerminalManagerWithPTY(factory PTYFactory) *TerminalManager {
 	return &TerminalManager{
 		sessions:    make(map[string]*TerminalSession),
 		sessionPool: make(map[string][]*TerminalSession),
+		ptyFactory:  factory,
 	}
 }
 
 // CreateSessionOptions holds options for creating a terminal session via TerminalManager
 type CreateSessionOptions struct {
-	SessionID    string
-	TerminalType string
+	SessionID    string            `json:"sessionID"`
+	TerminalType string            `json:"terminalType"`
+	Title        string            `json:"title,omitempty"`
+	ServicePins  map[string]string `json:"servicePins,omitempty"`
+	Services     []string          `json:"services,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	Executable   string            `json:"executable,omitempty"`
+	Args         []string          `json:"args,omitempty"`
+	Dir          string            `json:"dir,omitempty"`
+	// InitialCommand is typed into the shell once it has started
+	InitialCommand string                  `json:"initialCommand,omitempty"`
+	Timeout        *config.TerminalTimeout `json:"timeout,omitempty"`
+	// ScrollbackSize is the number of output bytes kept for reattaching; 0 uses DefaultScrollbackSize
+	ScrollbackSize int `json:"scrollbackSize,omitempty"`
+	// Replay is placed in the scrollback before the shell starts
+	Replay string `json:"-"`
 }
 
 // CreateSession creates a new terminal session with the specified options
@@ -462,12 +791,20 @@ func (tm *TerminalManager) CreateSession(opts CreateSessionOptions) (*TerminalSe
 	}
 
 	session := NewTerminalSessionWithOptions(TerminalSessionOptions(opts))
+	session.SetPTYFactory(tm.ptyFactory)
 
 	session.SetTimeoutCallback(func(id string) {
-		tm.removeSession(id)
+		tm.RemoveSession(id)
 	})
+	// The state saved below has no pid yet, so save again once the shell is running
+	session.onStart = func(string) {
+		tm.mutex.Lock()
+		defer tm.mutex.Unlock()
+		tm.saveState()
+	}
 
 	tm.sessions[opts.SessionID] = session
+	tm.saveState()
 	return session, nil
 }
 
@@ -498,8 +835,13 @@ func (tm *TerminalManager) removeSession(sessionID string) error {
 		return fmt.Errorf("session %s not found", sessionID)
 	}
 
+	if session.IsRecording() {
+		session.StopRecording()
+	}
 	session.Stop()
 	delete(tm.sessions, sessionID)
+	tm.rememberEnded(session)
+	tm.saveState()
 	return nil
 }
 
@@ -523,4 +865,7 @@ func (tm *TerminalManager) CleanupAll() {
 	for id := range tm.sessions {
 		tm.removeSession(id)
 	}
+	if tm.statePath != "" {
+		os.Remove(tm.statePath)
+	}
 }
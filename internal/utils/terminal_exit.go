@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// exitDrainTimeout is how long output is still read after the shell exits, for example while
+// background jobs that inherited the terminal keep it open
+const exitDrainTimeout = 2 * time.Second
+
+// maxEndedSessions is the number of removed sessions whose status remains available
+const maxEndedSessions = 32
+
+// exitStatus records how the shell process ended
+type exitStatus struct {
+	code     int
+	signal   string
+	exitedAt time.Time
+}
+
+// waitProcess collects the exit status of the shell, then ends the session once its output is read
+func (ts *TerminalSession) waitProcess(p PTY) {
+	code, signal := p.Wait()
+	status := &exitStatus{code: code, signal: signal, exitedAt: time.Now()}
+
+	ts.mutex.Lock()
+	ts.exit = status
+	ts.mutex.Unlock()
+
+	select {
+	case <-ts.outputDone:
+	case <-ts.stopChannel:
+	case <-time.After(exitDrainTimeout):
+		// Closing the PTY ends the pending read
+		p.Close()
+	}
+
+	ts.mutex.Lock()
+	if ts.isRunning {
+		ts.shutdownLocked()
+	}
+	ts.mutex.Unlock()
+}
+
+// exitMessage describes how the session ended
+func (ts *TerminalSession) exitMessage() string {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	switch {
+	case ts.exit == nil:
+		return "Terminal session ended"
+	case ts.exit.signal != "":
+		return fmt.Sprintf("Process terminated by signal: %s", ts.exit.signal)
+	case ts.exit.code >= 0:
+		return fmt.Sprintf("Process exited with code %d", ts.exit.code)
+	default:
+		return "Terminal session ended"
+	}
+}
+
+// Info returns the state of the session and, once it has ended, how the shell exited
+func (ts *TerminalSession) Info() SessionInfo {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	info := SessionInfo{
+		SessionID:    ts.sessionID,
+		TerminalType: ts.terminalType,
+		Title:        ts.title,
+		LastActivity: ts.lastActivity,
+		PID:          ts.pid,
+		Cwd:          ts.dir,
+		Running:      ts.isRunning,
+	}
+	if ts.shellPath != "" {
+		info.Shell = shellName(ts.shellPath)
+	}
+	if !ts.startedAt.IsZero() {
+		startedAt := ts.startedAt
+		info.StartedAt = &startedAt
+	}
+	if ts.isRunning && ts.pid > 0 {
+		if cwd, ok := processCwd(ts.pid); ok {
+			info.Cwd = cwd
+		}
+	}
+	if ts.exit != nil {
+		exitedAt := ts.exit.exitedAt
+		info.ExitedAt = &exitedAt
+		info.Signal = ts.exit.signal
+		if ts.exit.code >= 0 {
+			code := ts.exit.code
+			info.ExitCode = &code
+		}
+	}
+	return info
+}
+
+// SessionInfo returns the status of a session, including sessions that ended recently
+func (tm *TerminalManager) SessionInfo(sessionID string) (*SessionInfo, error) {
+	session, err := tm.LookupSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	info := session.Info()
+	return &info, nil
+}
+
+// rememberEnded keeps a removed session so its exit status can still be queried (internal, assumes lock is held)
+func (tm *TerminalManager) rememberEnded(session *TerminalSession) {
+	tm.ended = append(tm.ended, session)
+	if len(tm.ended) > maxEndedSessions {
+		tm.ended = tm.ended[len(tm.ended)-maxEndedSessions:]
+	}
+}
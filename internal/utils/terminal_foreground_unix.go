@@ -0,0 +1,37 @@
+//go:build !windows
+
+package utils
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// ForegroundProcessGroup returns the process group currently in the foreground of the terminal,
+// such as a command started from the shell, or the shell itself when it is waiting for input
+func (ts *TerminalSession) ForegroundProcessGroup() (int, error) {
+	ts.mutex.RLock()
+	p, isRunning := ts.pty, ts.isRunning
+	ts.mutex.RUnlock()
+
+	if !isRunning || p == nil {
+		return 0, fmt.Errorf("terminal is not running")
+	}
+	unixPty, ok := p.(interface{ Control(func(fd uintptr)) error })
+	if !ok {
+		return 0, fmt.Errorf("terminal has no process groups")
+	}
+
+	var pgid int
+	var ioctlErr error
+	if err := unixPty.Control(func(fd uintptr) {
+		pgid, ioctlErr = unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	}); err != nil {
+		return 0, err
+	}
+	if ioctlErr != nil {
+		return 0, fmt.Errorf("failed to get foreground process group: %w", ioctlErr)
+	}
+	return pgid, nil
+}
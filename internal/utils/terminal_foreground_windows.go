@@ -0,0 +1,10 @@
+//go:build windows
+
+package utils
+
+import "fmt"
+
+// ForegroundProcessGroup is not available for ConPTY sessions, as Windows has no process groups
+func (ts *TerminalSession) ForegroundProcessGroup() (int, error) {
+	return 0, fmt.Errorf("process groups are not supported on Windows")
+}
@@ -0,0 +1,64 @@
+package utils
+
+import "fmt"
+
+// OutputRecorder receives a copy of a session's output and size changes, e.g. an asciicast file
+type OutputRecorder interface {
+	Output(data string) error
+	Resize(cols, rows int) error
+	Close() error
+}
+
+// defaultCols and defaultRows are assumed until the UI sends the first resize
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// Size returns the last size the terminal was resized to
+func (ts *TerminalSession) Size() (cols, rows int) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	if ts.cols == 0 || ts.rows == 0 {
+		return defaultCols, defaultRows
+	}
+	return ts.cols, ts.rows
+}
+
+// StartRecording sends the session output to recorder until StopRecording is called or the session ends
+func (ts *TerminalSession) StartRecording(recorder OutputRecorder) error {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.recorder != nil {
+		return fmt.Errorf("session %s is already being recorded", ts.sessionID)
+	}
+
+	ts.recorder = recorder
+	ts.stopRecorder = ts.Subscribe(
+		func(data string) { recorder.Output(data) },
+		func(string) { ts.StopRecording() },
+	)
+	return nil
+}
+
+// StopRecording detaches and closes the active recorder
+func (ts *TerminalSession) StopRecording() error {
+	ts.mutex.Lock()
+	recorder, stop := ts.recorder, ts.stopRecorder
+	ts.recorder, ts.stopRecorder = nil, nil
+	ts.mutex.Unlock()
+
+	if recorder == nil {
+		return fmt.Errorf("session %s is not being recorded", ts.sessionID)
+	}
+	stop()
+	return recorder.Close()
+}
+
+// IsRecording returns whether the session output is being recorded
+func (ts *TerminalSession) IsRecording() bool {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return ts.recorder != nil
+}
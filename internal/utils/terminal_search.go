@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/JadlionHD/Enty/internal/ansi"
+)
+
+// maxSearchResults bounds the lines returned by SearchOutput
+const maxSearchResults = 1000
+
+// OutputMatch is a line of session output that matches a search
+type OutputMatch struct {
+	// Line is the line number within the scrollback, starting at 1
+	Line int `json:"line"`
+	// Offset is the output stream position the line starts at, comparable with terminal:data offsets
+	Offset int64 `json:"offset"`
+	// Text is the line without escape sequences
+	Text string `json:"text"`
+	// Matches are the byte ranges of each match within Text
+	Matches [][2]int `json:"matches"`
+}
+
+// Transcript returns the buffered output and the stream position it starts at. When older output
+// has been dropped, the transcript starts at the first complete line.
+func (ts *TerminalSession) Transcript() (string, int64) {
+	data, end := ts.Scrollback()
+	start := end - int64(len(data))
+	if start > 0 {
+		if i := strings.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+			start += int64(i + 1)
+		}
+	}
+	return data, start
+}
+
+// SearchOutput returns the lines of the scrollback that contain pattern. Plain patterns match
+// case-insensitively; with regex set, pattern is a Go regular expression.
+func (ts *TerminalSession) SearchOutput(pattern string, regex bool) ([]OutputMatch, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("search pattern is empty")
+	}
+	if !regex {
+		pattern = "(?i)" + regexp.QuoteMeta(pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+
+	data, offset := ts.Transcript()
+	results := []OutputMatch{}
+	for number, raw := range strings.SplitAfter(data, "\n") {
+		lineOffset := offset
+		offset += int64(len(raw))
+
+		text := strings.TrimSuffix(ansi.Strip(raw), "\n")
+		locations := re.FindAllStringIndex(text, -1)
+		if len(locations) == 0 {
+			continue
+		}
+
+		match := OutputMatch{Line: number + 1, Offset: lineOffset, Text: text}
+		for _, location := range locations {
+			match.Matches = append(match.Matches, [2]int{location[0], location[1]})
+		}
+		results = append(results, match)
+		if len(results) == maxSearchResults {
+			break
+		}
+	}
+	return results, nil
+}
+
+// LookupSession returns an active session or one that ended recently, whose output can still be read
+func (tm *TerminalManager) LookupSession(sessionID string) (*TerminalSession, error) {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	if session, exists := tm.sessions[sessionID]; exists {
+		return session, nil
+	}
+	for i := len(tm.ended) - 1; i >= 0; i-- {
+		if tm.ended[i].sessionID == sessionID {
+			return tm.ended[i], nil
+		}
+	}
+	return nil, fmt.Errorf("session %s not found", sessionID)
+}
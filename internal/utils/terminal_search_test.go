@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// searchSession returns a session whose scrollback holds output
+func searchSession(t *testing.T, output string, scrollbackSize int) *TerminalSession {
+	t.Helper()
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	t.Cleanup(tm.CleanupAll)
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one", ScrollbackSize: scrollbackSize})
+
+	loop := startReadLoop(session)
+	pty.Emit(output)
+	loop.waitForOutput(t, output)
+	return session
+}
+
+func TestSearchOutput(t *testing.T) {
+	output := "$ composer install\r\n" +
+		"\x1b[32mInstalling\x1b[0m laravel/framework\r\n" +
+		"Downloading 10%\rDownloading 100%\r\n" +
+		"\x1b[31mERROR\x1b[0m: Error in config\r\n" +
+		"$ "
+	session := searchSession(t, output, 0)
+
+	cases := []struct {
+		pattern string
+		regex   bool
+		want    []OutputMatch
+	}{
+		{"laravel", false, []OutputMatch{
+			{Line: 2, Offset: 20, Text: "Installing laravel/framework", Matches: [][2]int{{11, 18}}},
+		}},
+		// Overwritten progress is searched as it ends up on screen
+		{"100%", false, []OutputMatch{
+			{Line: 3, Offset: 59, Text: "Downloading 100%", Matches: [][2]int{{12, 16}}},
+		}},
+		{"10%", false, []OutputMatch{}},
+		{"error", false, []OutputMatch{
+			{Line: 4, Offset: 93, Text: "ERROR: Error in config", Matches: [][2]int{{0, 5}, {7, 12}}},
+		}},
+		{`^\$ \w+`, true, []OutputMatch{
+			{Line: 1, Offset: 0, Text: "$ composer install", Matches: [][2]int{{0, 10}}},
+		}},
+		{"[32m", false, []OutputMatch{}},
+	}
+	for _, tc := range cases {
+		got, err := session.SearchOutput(tc.pattern, tc.regex)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.pattern, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("SearchOutput(%q):\ngot  %+v\nwant %+v", tc.pattern, got, tc.want)
+		}
+	}
+
+	// Offsets point at the start of each line in the raw stream
+	for _, match := range []OutputMatch{{Offset: 20}, {Offset: 59}, {Offset: 93}} {
+		if output[match.Offset-2:match.Offset] != "\r\n" {
+			t.Fatalf("offset %d is not at a line start", match.Offset)
+		}
+	}
+
+	if _, err := session.SearchOutput("", false); err == nil {
+		t.Fatal("empty pattern accepted")
+	}
+	if _, err := session.SearchOutput("(", true); err == nil {
+		t.Fatal("invalid regex accepted")
+	}
+}
+
+func TestTranscriptStartsAtCompleteLine(t *testing.T) {
+	// The 16 byte scrollback keeps "ine two\r\nthree\r\n", which starts inside a line
+	session := searchSession(t, "line one\r\nline two\r\nthree\r\n", 16)
+
+	data, start := session.Transcript()
+	if data != "three\r\n" || start != 20 {
+		t.Fatalf("Transcript = %q, %d; want %q, 20", data, start, "three\r\n")
+	}
+
+	matches, err := session.SearchOutput("three", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Line != 1 || matches[0].Offset != 20 {
+		t.Fatalf("matches %+v", matches)
+	}
+}
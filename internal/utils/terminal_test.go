@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JadlionHD/Enty/internal/config"
+)
+
+// startFakeSession creates and starts a session whose shell is a FakePTY
+func startFakeSession(t *testing.T, tm *TerminalManager, factory *FakePTYFactory, opts CreateSessionOptions) (*TerminalSession, *FakePTY) {
+	t.Helper()
+	if opts.TerminalType == "" {
+		opts.TerminalType = "bash"
+	}
+	session, err := tm.CreateSession(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return session, factory.Last()
+}
+
+// readLoop collects what StartReadLoop delivers
+type readLoop struct {
+	mutex  sync.Mutex
+	output strings.Builder
+	exited chan string
+}
+
+func startReadLoop(session *TerminalSession) *readLoop {
+	r := &readLoop{exited: make(chan string, 1)}
+	session.StartReadLoop(func(data string) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.output.WriteString(data)
+	}, func(message string) {
+		r.exited <- message
+	})
+	return r
+}
+
+func (r *readLoop) waitForOutput(t *testing.T, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.mutex.Lock()
+		got := r.output.String()
+		r.mutex.Unlock()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("output %q, want %q", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (r *readLoop) waitForExit(t *testing.T) string {
+	t.Helper()
+	select {
+	case message := <-r.exited:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("read loop did not report an exit")
+		return ""
+	}
+}
+
+func TestSessionOutputReachesReadLoop(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one"})
+
+	loop := startReadLoop(session)
+	pty.Emit("hello ")
+	pty.Emit("world\r\n")
+	loop.waitForOutput(t, "hello world\r\n")
+
+	if scrollback, _ := session.Scrollback(); scrollback != "hello world\r\n" {
+		t.Fatalf("scrollback %q", scrollback)
+	}
+}
+
+func TestSessionWriteAndResize(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one", InitialCommand: "cd /srv"})
+
+	if err := session.Write("ls\r"); err != nil {
+		t.Fatal(err)
+	}
+	if !pty.WaitForInput("cd /srv\rls\r", 5*time.Second) {
+		t.Fatalf("input %q", pty.Input())
+	}
+
+	if err := session.Resize(132, 43); err != nil {
+		t.Fatal(err)
+	}
+	if cols, rows := pty.Size(); cols != 132 || rows != 43 {
+		t.Fatalf("size %dx%d, want 132x43", cols, rows)
+	}
+}
+
+func TestSessionShellExit(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one"})
+
+	loop := startReadLoop(session)
+	pty.Emit("logout\r\n")
+	pty.Exit(3)
+
+	if message := loop.waitForExit(t); message != "Process exited with code 3" {
+		t.Fatalf("exit message %q", message)
+	}
+	// Output written before the exit is delivered before the exit message
+	loop.waitForOutput(t, "logout\r\n")
+
+	info := session.Info()
+	if info.Running || info.ExitCode == nil || *info.ExitCode != 3 {
+		t.Fatalf("info %+v", info)
+	}
+	if err := session.Write("ls\r"); err == nil {
+		t.Fatal("write to an exited session succeeded")
+	}
+	if !pty.IsClosed() {
+		t.Fatal("pty was not closed")
+	}
+}
+
+func TestSessionInactivityTimeout(t *testing.T) {
+	timeoutUnit = 20 * time.Millisecond
+	defer func() { timeoutUnit = time.Minute }()
+
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+
+	timeout := config.TerminalTimeout{Minutes: 1, Action: config.TimeoutActionKill}
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "idle", Timeout: &timeout})
+	loop := startReadLoop(session)
+
+	loop.waitForExit(t)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(tm.ListSessions()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out session not removed: %v", tm.ListSessions())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !pty.IsClosed() {
+		t.Fatal("pty was not closed")
+	}
+
+	// The detach action keeps the shell running
+	detached := make(chan string, 1)
+	timeout.Action = config.TimeoutActionDetach
+	session, err := tm.CreateSession(CreateSessionOptions{SessionID: "detach", TerminalType: "bash", Timeout: &timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Callbacks are set before Start, as the timers read them without the lock
+	session.SetDetachCallback(func(id string) { detached <- id })
+	if err := session.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pty = factory.Last()
+	select {
+	case id := <-detached:
+		if id != "detach" {
+			t.Fatalf("detached %q", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("detach callback not called")
+	}
+	if !session.IsRunning() || pty.IsClosed() {
+		t.Fatal("detached session was stopped")
+	}
+}
+
+func TestSessionConcurrentStop(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("s%d", i)
+		session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: id})
+		startReadLoop(session)
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// More writes than the write buffer holds, so the direct fallback is used as well
+				for j := 0; j < 100; j++ {
+					session.Write("x")
+					session.Resize(80+j, 24)
+				}
+			}()
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pty.Emit("output")
+		}()
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				session.Stop()
+			} else {
+				tm.RemoveSession(id)
+			}
+		}()
+		wg.Wait()
+
+		if err := session.Write("x"); err == nil && !session.IsRunning() {
+			t.Fatal("write to a stopped session succeeded")
+		}
+		tm.RemoveSession(id)
+		if session.IsRunning() {
+			t.Fatalf("%s still running after RemoveSession", id)
+		}
+	}
+}
+
+func TestSessionWriteFallbackDuringStop(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("s%d", i)
+		session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: id})
+
+		// With the shell not reading, the write buffer fills and Write falls back to the PTY
+		pty.BlockWrites(true)
+		for len(session.writeBuffer) < cap(session.writeBuffer) {
+			session.Write("x")
+		}
+		var wg sync.WaitGroup
+		for w := 0; w < 40; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				session.Write("x")
+			}()
+		}
+		time.Sleep(10 * time.Millisecond)
+		if i%2 == 0 {
+			session.Stop()
+		} else {
+			tm.RemoveSession(id)
+		}
+		// Closing the PTY ends the blocked writes
+		wg.Wait()
+
+		if err := session.Write("x"); err == nil {
+			t.Fatal("write to a stopped session succeeded")
+		}
+	}
+}
+
+func TestSubscribeAfterExit(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+	session, pty := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one"})
+
+	loop := startReadLoop(session)
+	pty.Emit("bye\r\n")
+	pty.Exit(0)
+	message := loop.waitForExit(t)
+
+	// A viewer that subscribes once the session has exited is told right away
+	exited := make(chan string, 1)
+	unsubscribe := session.Subscribe(func(string) {}, func(message string) { exited <- message })
+	defer unsubscribe()
+	select {
+	case got := <-exited:
+		if got != message {
+			t.Fatalf("exit message %q, want %q", got, message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("late subscriber was not told about the exit")
+	}
+	if session.ViewerCount() != 0 {
+		t.Fatalf("%d viewers attached to an exited session", session.ViewerCount())
+	}
+
+	var replay string
+	session.Attach(func(data string) { replay += data }, func(message string) { exited <- message })
+	if replay != "bye\r\n" || len(exited) != 1 {
+		t.Fatalf("attach after exit: replay %q, %d exit messages", replay, len(exited))
+	}
+}
+
+func TestInterrupted(t *testing.T) {
+	factory := NewFakePTYFactory()
+	tm := NewTerminalManagerWithPTY(factory)
+	defer tm.CleanupAll()
+	session, _ := startFakeSession(t, tm, factory, CreateSessionOptions{SessionID: "one"})
+
+	interrupted := session.Interrupted()
+	session.Write("ls\r")
+	select {
+	case <-interrupted:
+		t.Fatal("ordinary input counted as an interrupt")
+	default:
+	}
+
+	session.Write("\x03")
+	select {
+	case <-interrupted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Ctrl-C did not interrupt")
+	}
+
+	// Each interrupt wakes only those waiting at the time
+	interrupted = session.Interrupted()
+	session.NotifyInterrupt()
+	select {
+	case <-interrupted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("NotifyInterrupt did not interrupt")
+	}
+	select {
+	case <-session.Interrupted():
+		t.Fatal("a past interrupt woke a new waiter")
+	default:
+	}
+}
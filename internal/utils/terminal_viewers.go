@@ -0,0 +1,125 @@
+package utils
+
+// terminalViewer receives a copy of a session's output in addition to the StartReadLoop callbacks
+type terminalViewer struct {
+	onData TerminalReadCallback
+	onExit TerminalExitCallback
+}
+
+// Subscribe attaches an additional viewer to the session output, e.g. a WebSocket client.
+// Several viewers can be attached at once; the returned function detaches this one.
+// When the session has already exited, onExit is called right away.
+func (ts *TerminalSession) Subscribe(onData func(data string), onExit func(message string)) (unsubscribe func()) {
+	ts.viewersMutex.Lock()
+	unsubscribe, exitMessage := ts.subscribeLocked(onData, onExit)
+	ts.viewersMutex.Unlock()
+
+	if exitMessage != nil && onExit != nil {
+		onExit(*exitMessage)
+	}
+	return unsubscribe
+}
+
+// subscribeLocked registers a viewer, or returns the exit message if the session has already
+// exited (internal, assumes viewersMutex is held)
+func (ts *TerminalSession) subscribeLocked(onData func(data string), onExit func(message string)) (func(), *string) {
+	if ts.exitMessageSent != nil {
+		return func() {}, ts.exitMessageSent
+	}
+	if ts.viewers == nil {
+		ts.viewers = make(map[int]terminalViewer)
+	}
+	ts.nextViewerID++
+	id := ts.nextViewerID
+	ts.viewers[id] = terminalViewer{onData: onData, onExit: onExit}
+
+	return func() {
+		ts.viewersMutex.Lock()
+		defer ts.viewersMutex.Unlock()
+		delete(ts.viewers, id)
+	}, nil
+}
+
+// ViewerCount returns the number of viewers attached with Subscribe
+func (ts *TerminalSession) ViewerCount() int {
+	ts.viewersMutex.RLock()
+	defer ts.viewersMutex.RUnlock()
+	return len(ts.viewers)
+}
+
+// Attach subscribes a viewer after passing it the scrollback through onData. Output is recorded
+// under the same lock, so nothing is lost or duplicated between the replay and the live stream.
+// offset is the stream position the replay ends at. onData must not block.
+func (ts *TerminalSession) Attach(onData func(data string), onExit func(message string)) (offset int64, unsubscribe func()) {
+	ts.viewersMutex.Lock()
+	replay, offset := ts.scrollbackLocked()
+	if replay != "" && onData != nil {
+		onData(replay)
+	}
+	unsubscribe, exitMessage := ts.subscribeLocked(onData, onExit)
+	ts.viewersMutex.Unlock()
+
+	if exitMessage != nil && onExit != nil {
+		onExit(*exitMessage)
+	}
+	return offset, unsubscribe
+}
+
+// Scrollback returns the buffered output and the stream position it ends at
+func (ts *TerminalSession) Scrollback() (string, int64) {
+	ts.viewersMutex.RLock()
+	defer ts.viewersMutex.RUnlock()
+	return ts.scrollbackLocked()
+}
+
+// OutputOffset returns the total number of output bytes produced by the session.
+// Called from a StartReadLoop callback, it is the stream position after the current chunk.
+func (ts *TerminalSession) OutputOffset() int64 {
+	ts.viewersMutex.RLock()
+	defer ts.viewersMutex.RUnlock()
+	if ts.scrollback == nil {
+		return 0
+	}
+	return ts.scrollback.total
+}
+
+func (ts *TerminalSession) scrollbackLocked() (string, int64) {
+	if ts.scrollback == nil {
+		return "", 0
+	}
+	return string(ts.scrollback.Bytes()), ts.scrollback.total
+}
+
+// recordOutput appends output to the scrollback and returns the viewers to deliver it to
+func (ts *TerminalSession) recordOutput(data []byte) []terminalViewer {
+	ts.viewersMutex.Lock()
+	defer ts.viewersMutex.Unlock()
+
+	if ts.scrollback != nil {
+		ts.scrollback.Write(data)
+	}
+	viewers := make([]terminalViewer, 0, len(ts.viewers))
+	for _, viewer := range ts.viewers {
+		viewers = append(viewers, viewer)
+	}
+	return viewers
+}
+
+// broadcastExit notifies and detaches every attached viewer. Viewers that subscribe later are
+// given the same message.
+func (ts *TerminalSession) broadcastExit(message string) {
+	ts.viewersMutex.Lock()
+	viewers := make([]terminalViewer, 0, len(ts.viewers))
+	for _, viewer := range ts.viewers {
+		viewers = append(viewers, viewer)
+	}
+	ts.viewers = nil
+	ts.exitMessageSent = &message
+	ts.viewersMutex.Unlock()
+
+	for _, viewer := range viewers {
+		if viewer.onExit != nil {
+			viewer.onExit(message)
+		}
+	}
+}
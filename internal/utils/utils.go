@@ -4,14 +4,17 @@ import (
 	"context"
 
 	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/events"
 )
 
 type utils struct {
 	ctx context.Context
+	bus events.Bus
 }
 
-func Utils() *utils {
-	return &utils{}
+// Utils creates the utils binding, publishing download events on bus
+func Utils(bus events.Bus) *utils {
+	return &utils{bus: bus}
 }
 
 func (u *utils) Start(ctx context.Context) {
@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"embed"
+	"os"
 
 	"github.com/JadlionHD/Enty/internal/config"
+	"github.com/JadlionHD/Enty/internal/events/wailsbus"
+	"github.com/JadlionHD/Enty/internal/shellenv"
+	"github.com/JadlionHD/Enty/internal/shim"
 	"github.com/JadlionHD/Enty/internal/utils"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -15,9 +19,20 @@ import (
 var assets embed.FS
 
 func main() {
+	// Shims re-enter the executable to run the right service binary without starting the GUI
+	if len(os.Args) > 1 && os.Args[1] == shim.Command {
+		os.Exit(shim.Run(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == shellenv.Command {
+		os.Exit(shellenv.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Backend packages publish events through the bus, which forwards them to the frontend
+	bus := wailsbus.New()
+
 	// Create an instance of the app structure
-	app := NewApp()
-	utils := utils.Utils()
+	app := NewApp(bus)
+	utils := utils.Utils(bus)
 	configs := config.Config()
 
 	// Create application with options
@@ -34,10 +49,13 @@ func main() {
 		Frameless:        true,
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup: func(ctx context.Context) {
+			bus.Start(ctx)
 			app.startup(ctx)
 			configs.Start(ctx)
 			utils.Start(ctx)
 		},
+		OnBeforeClose: app.beforeClose,
+		OnShutdown:    app.shutdown,
 		Bind: []interface{}{
 			app,
 			configs,